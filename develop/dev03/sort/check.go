@@ -0,0 +1,59 @@
+package sort
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// runCheck checks the first input and reports the first line that is
+// out of order unless check is quiet
+func runCheck(ctx context.Context, s *Sorter, opt *options) error {
+	if len(opt.inputs) == 0 {
+		return nil
+	}
+
+	in := opt.inputs[0]
+	d, err := s.Check(ctx, in.reader)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return nil
+	}
+
+	if !opt.isCheckQuiet {
+		fmt.Fprintf(os.Stderr, "sort: %s:%d: disorder: %s\n", in.name, d.Line, d.Text)
+	}
+	return errDisorder
+}
+
+// checkSorted reads data line by line and stops at the first line that is
+// out of order. With unique option equal lines are out of order too.
+func checkSorted(ctx context.Context, opt *options, r io.Reader) (*Disorder, error) {
+	scanner := bufio.NewScanner(r)
+	cmp := newLineComparator(opt)
+
+	var prev line
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		if lineNum%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		cur := cmp.parse(scanner.Text())
+		if lineNum > 1 && isDisorder(cmp.compare(prev, cur), opt.isUniqueOnly) {
+			return &Disorder{Line: lineNum, Text: cur.text}, nil
+		}
+		prev = cur
+	}
+
+	return nil, scanner.Err()
+}
+
+func isDisorder(cmpRes int, isStrict bool) bool {
+	return cmpRes > 0 || (isStrict && cmpRes == 0)
+}
@@ -0,0 +1,76 @@
+package sort
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCheck(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     []string
+		opt      options
+		isSorted bool
+	}{
+		{
+			name:     "sorted",
+			data:     []string{"a", "b", "b", "c"},
+			opt:      options{keys: []sortKey{newWholeLineKey(sortTypeDefautl, false)}},
+			isSorted: true,
+		},
+		{
+			name:     "not sorted",
+			data:     []string{"a", "c", "b"},
+			opt:      options{keys: []sortKey{newWholeLineKey(sortTypeDefautl, false)}},
+			isSorted: false,
+		},
+		{
+			name:     "equal lines are disorder for unique",
+			data:     []string{"a", "b", "b", "c"},
+			opt:      options{keys: []sortKey{newWholeLineKey(sortTypeDefautl, false)}, isUniqueOnly: true},
+			isSorted: false,
+		},
+		{
+			name:     "reverse",
+			data:     []string{"c", "b", "a"},
+			opt:      options{keys: []sortKey{newWholeLineKey(sortTypeDefautl, true)}, isDescOrder: true},
+			isSorted: true,
+		},
+		{
+			name:     "numeric column",
+			data:     []string{"x", "b 2", "a 10"},
+			opt:      options{keys: []sortKey{newFieldKey(1, sortTypeNumeric, false)}},
+			isSorted: true,
+		},
+		{
+			name:     "numeric column disorder",
+			data:     []string{"b 2", "a 10", "x"},
+			opt:      options{keys: []sortKey{newFieldKey(1, sortTypeNumeric, false)}},
+			isSorted: false,
+		},
+		{
+			name:     "empty",
+			data:     []string{},
+			opt:      options{keys: []sortKey{newWholeLineKey(sortTypeDefautl, false)}},
+			isSorted: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt := tc.opt
+			opt.isCheckIfSorted = true
+			opt.isCheckQuiet = true
+			opt.inputs = []input{newStringInput("-", tc.data)}
+
+			err := run(context.Background(), &opt)
+			if tc.isSorted {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, errDisorder)
+		})
+	}
+}
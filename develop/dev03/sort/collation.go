@@ -0,0 +1,40 @@
+package sort
+
+import (
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// collator converts strings to binary keys that give the order of the
+// locale when compared as plain strings. It is not safe for concurrent use.
+type collator struct {
+	exact *collate.Collator
+	fold  *collate.Collator
+	buf   collate.Buffer
+}
+
+func newCollator(locale string) *collator {
+	tag := language.MustParse(locale)
+	return &collator{
+		exact: collate.New(tag),
+		fold:  collate.New(tag, collate.IgnoreCase),
+	}
+}
+
+func (c *collator) key(s string, isFoldCase bool) string {
+	col := c.exact
+	if isFoldCase {
+		col = c.fold
+	}
+
+	key := string(col.KeyFromString(&c.buf, s))
+	c.buf.Reset()
+	return key
+}
+
+func validateLocale(locale string) error {
+	if _, err := language.Parse(locale); err != nil {
+		return errInvalidLocale
+	}
+	return nil
+}
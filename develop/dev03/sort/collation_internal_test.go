@@ -0,0 +1,92 @@
+package sort
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComparisonModes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     []string
+		key      sortKey
+		locale   string
+		expected []string
+	}{
+		{
+			name:     "bytes order",
+			data:     []string{"b", "B", "a", "_c", "A"},
+			key:      sortKey{endField: keyEndOfLine},
+			expected: []string{"A", "B", "_c", "a", "b"},
+		},
+		{
+			name:     "fold case",
+			data:     []string{"b", "B", "a", "_c", "A"},
+			key:      sortKey{endField: keyEndOfLine, isFoldCase: true},
+			expected: []string{"A", "a", "B", "b", "_c"},
+		},
+		{
+			name:     "dictionary order",
+			data:     []string{"(c)", "b-", "a.z", "ab"},
+			key:      sortKey{endField: keyEndOfLine, isDictionaryOrder: true},
+			expected: []string{"ab", "a.z", "b-", "(c)"},
+		},
+		{
+			name:     "dictionary order cyrillic",
+			data:     []string{"«в»", "б", "-а"},
+			key:      sortKey{endField: keyEndOfLine, isDictionaryOrder: true},
+			expected: []string{"-а", "б", "«в»"},
+		},
+		{
+			name:     "ignore nonprinting",
+			data:     []string{"b", "\x01c", "a\x02"},
+			key:      sortKey{endField: keyEndOfLine, isIgnoreNonPrinting: true},
+			expected: []string{"a\x02", "b", "\x01c"},
+		},
+		{
+			name:     "bytes order yo",
+			data:     []string{"ёж", "еда", "жук", "Ель"},
+			key:      sortKey{endField: keyEndOfLine},
+			expected: []string{"Ель", "еда", "жук", "ёж"},
+		},
+		{
+			name:     "russian locale yo",
+			data:     []string{"ёж", "еда", "жук", "Ель"},
+			key:      sortKey{endField: keyEndOfLine},
+			locale:   "ru",
+			expected: []string{"еда", "ёж", "Ель", "жук"},
+		},
+		{
+			name:     "russian locale column",
+			data:     []string{"1 ёлка", "2 Яблоко", "3 арбуз"},
+			key:      sortKey{startField: 1, endField: 1, isIgnoreLeadingBlanks: true},
+			locale:   "ru",
+			expected: []string{"3 арбуз", "1 ёлка", "2 Яблоко"},
+		},
+		{
+			name:     "locale fold case",
+			data:     []string{"b", "A", "a", "B"},
+			key:      sortKey{endField: keyEndOfLine, isFoldCase: true},
+			locale:   "en",
+			expected: []string{"A", "a", "B", "b"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt := &options{
+				keys:   []sortKey{tc.key},
+				locale: tc.locale,
+			}
+			d := append([]string{}, tc.data...)
+			assert.Equal(t, tc.expected, sortData(opt, d))
+		})
+	}
+}
+
+func TestValidateLocale(t *testing.T) {
+	assert.NoError(t, validateLocale("ru"))
+	assert.NoError(t, validateLocale("en-US"))
+	assert.ErrorIs(t, validateLocale("not a locale"), errInvalidLocale)
+}
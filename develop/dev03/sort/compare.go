@@ -0,0 +1,214 @@
+package sort
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/exp/constraints"
+)
+
+// keyValue is a value of a single sort key of a line. Lines that have no
+// key value (field is absent or doesn't start with a general number or
+// a month name) go before the lines with value in ascending order. Numeric
+// and human numeric keys that are absent or not numbers are equal to zero.
+type keyValue struct {
+	isPresent bool
+	str       string
+	num       int
+	real      float64
+	decimal   decimalNumber
+	hash      uint64
+}
+
+// line is a source line with precomputed values of all sort keys
+type line struct {
+	text string
+	keys []keyValue
+}
+
+// lineComparator compares lines by the chain of keys: the next key
+// is used only if lines are equal by all previous keys. Lines equal by all
+// keys are compared as whole strings unless comparison is stable or unique.
+type lineComparator struct {
+	keys        []sortKey
+	splitter    fieldSplitter
+	collator    *collator
+	randomSalt  []byte
+	isStable    bool
+	isDescOrder bool
+}
+
+// newLineComparator returns comparator of lines, fields are separated
+// by blanks unless options have splitter and strings are compared byte by
+// byte unless options have locale
+func newLineComparator(opt *options) *lineComparator {
+	splitter := opt.splitter
+	if splitter == nil {
+		splitter = blankSplitter{}
+	}
+	c := &lineComparator{
+		keys:        opt.keys,
+		splitter:    splitter,
+		randomSalt:  opt.randomSalt,
+		isStable:    opt.isStable || opt.isUniqueOnly,
+		isDescOrder: opt.isDescOrder,
+	}
+	if opt.locale != "" {
+		c.collator = newCollator(opt.locale)
+	}
+	return c
+}
+
+func (c *lineComparator) parse(s string) line {
+	keys := make([]keyValue, len(c.keys))
+	for i := range c.keys {
+		keys[i] = c.getKeyValue(&c.keys[i], s)
+	}
+	return line{text: s, keys: keys}
+}
+
+func (c *lineComparator) parseAll(data []string) []line {
+	out := make([]line, len(data))
+	for i, v := range data {
+		out[i] = c.parse(v)
+	}
+	return out
+}
+
+// compare returns a negative number when a goes before b,
+// a positive number when b goes before a and 0 when they are equal
+func (c *lineComparator) compare(a, b line) int {
+	for i := range c.keys {
+		res := compareKeyValues(c.keys[i].sortType, a.keys[i], b.keys[i])
+		if res != 0 {
+			return reverseIf(res, c.keys[i].isDescOrder)
+		}
+	}
+
+	if c.isStable {
+		return 0
+	}
+	return reverseIf(strings.Compare(a.text, b.text), c.isDescOrder)
+}
+
+func reverseIf(res int, isReverse bool) int {
+	if isReverse {
+		return -res
+	}
+	return res
+}
+
+func (c *lineComparator) getKeyValue(key *sortKey, s string) keyValue {
+	chunk, ok := key.extract(s, c.splitter)
+	if !ok && key.sortType != sortTypeNumeric && key.sortType != sortTypeHumanNumeric {
+		return keyValue{}
+	}
+	if key.sortType != sortTypeDefautl && key.sortType != sortTypeRandom {
+		// numbers, month names and versions may be preceded by blanks
+		chunk = strings.TrimLeftFunc(chunk, unicode.IsSpace)
+	}
+
+	switch key.sortType {
+	case sortTypeDefautl:
+		return keyValue{isPresent: true, str: c.getStringKey(key, chunk)}
+	case sortTypeRandom:
+		str := c.getStringKey(key, chunk)
+		return keyValue{isPresent: true, str: str, hash: randomHash(c.randomSalt, str)}
+	case sortTypeVersion:
+		return keyValue{isPresent: true, str: chunk}
+	case sortTypeNumeric:
+		// as in GNU sort, key without number is zero
+		d, _ := getDecimalFromStringStart(chunk)
+		return keyValue{isPresent: true, decimal: d}
+	case sortTypeGeneralNumeric:
+		if d, err := getGeneralNumberFromStringStart(chunk); err == nil {
+			return keyValue{isPresent: true, real: d}
+		}
+	case sortTypeHumanNumeric:
+		order, d, _ := getHumanNumberFromStringStart(chunk)
+		return keyValue{isPresent: true, num: order, decimal: d}
+	case sortTypeMonth:
+		if d, err := getMonthFromStringStart(chunk); err == nil {
+			return keyValue{isPresent: true, num: d}
+		}
+	default:
+		panic("unknown sort type")
+	}
+	return keyValue{}
+}
+
+// getStringKey returns string that compares with keys of other lines
+// according to the ordering options of the key and the locale
+func (c *lineComparator) getStringKey(key *sortKey, s string) string {
+	if key.isDictionaryOrder {
+		s = strings.Map(keepRune(isDictionaryRune), s)
+	}
+	if key.isIgnoreNonPrinting {
+		s = strings.Map(keepRune(unicode.IsPrint), s)
+	}
+
+	if c.collator != nil {
+		return c.collator.key(s, key.isFoldCase)
+	}
+	if key.isFoldCase {
+		return strings.ToUpper(s)
+	}
+	return s
+}
+
+// keepRune returns mapping for strings.Map that drops runes not matched
+// by the filter
+func keepRune(filter func(rune) bool) func(rune) rune {
+	return func(r rune) rune {
+		if filter(r) {
+			return r
+		}
+		return -1
+	}
+}
+
+// isDictionaryRune reports whether rune is blank, letter or digit
+func isDictionaryRune(r rune) bool {
+	return r == ' ' || r == '\t' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func compareKeyValues(sortType int, a, b keyValue) int {
+	if a.isPresent != b.isPresent {
+		if a.isPresent {
+			return 1
+		}
+		return -1
+	}
+
+	switch sortType {
+	case sortTypeNumeric:
+		return compareDecimals(a.decimal, b.decimal)
+	case sortTypeGeneralNumeric:
+		return compareGeneralNumbers(a.real, b.real)
+	case sortTypeMonth:
+		return compareOrdered(a.num, b.num)
+	case sortTypeHumanNumeric:
+		if res := compareOrdered(a.num, b.num); res != 0 {
+			return res
+		}
+		return compareDecimals(a.decimal, b.decimal)
+	case sortTypeVersion:
+		return compareVersions(a.str, b.str)
+	case sortTypeRandom:
+		// equal keys have equal hashes, so they are kept together
+		if res := compareOrdered(a.hash, b.hash); res != 0 {
+			return res
+		}
+	}
+	return strings.Compare(a.str, b.str)
+}
+
+func compareOrdered[T constraints.Ordered](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
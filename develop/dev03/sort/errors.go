@@ -7,8 +7,17 @@ import (
 
 var (
 	errInvalidSortCol         = fmt.Errorf("sort: sort column number starts with %d", minSortCol)
+	errInvalidSortKey         = errors.New("sort: invalid key definition")
+	errInvalidSeparator       = errors.New("sort: separator must be a single character")
+	errInvalidLocale          = errors.New("sort: unknown locale")
+	errCheckExtraOperand      = errors.New("sort: extra operand, only one file can be checked")
+	errInvalidParallel        = errors.New("sort: number of parallel goroutines must be positive")
 	errBadOpenFile            = errors.New("sort: can't read file")
 	errBadCreateFile          = errors.New("sort: can't create file")
 	errWriteFail              = errors.New("sort: can't write to file")
 	errStrNotStartsWithNumber = errors.New("sort: string not starts with number")
+	errStrNotStartsWithMonth  = errors.New("sort: string not starts with month name")
+	errDisorder               = errors.New("sort: data is not sorted")
+	errInvalidMemorySize      = errors.New("sort: invalid memory buffer size")
+	errInvalidRandomSource    = errors.New("sort: not enough bytes in random source")
 )
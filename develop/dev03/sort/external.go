@@ -0,0 +1,186 @@
+package sort
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// lineOverhead approximates memory used by a line in addition to its bytes
+	lineOverhead   = 32
+	tempRunPattern = "go-sort-run-*"
+	tempOutPattern = ".go-sort-out-*"
+	// mergeFanIn is the maximum number of runs merged at once, so number
+	// of open files stays bounded
+	mergeFanIn = 16
+)
+
+// sortExternal sorts data that may not fit into memory: input is split into
+// chunks that fit into memory budget, every chunk is sorted in memory and
+// spilled to temp file, then temp files are merged into the output
+func sortExternal(ctx context.Context, opt *options, scanner lineScanner, w *dataWriter) error {
+	runs := []string{}
+	defer func() { removeFiles(runs) }()
+
+	// duplicates are counted while merging, so runs must keep them
+	runOpt := opt
+	if opt.isCountUnique {
+		runOpt = &options{}
+		*runOpt = *opt
+		runOpt.isUniqueOnly, runOpt.isCountUnique, runOpt.isStable = false, false, true
+	}
+
+	for {
+		chunk, done, err := readChunk(scanner, opt.memoryBudget)
+		if err != nil {
+			return err
+		}
+		if done && len(runs) == 0 {
+			return writeLines(sortData(opt, chunk), w)
+		}
+		chunk = sortData(runOpt, chunk)
+
+		if len(chunk) > 0 {
+			path, err := writeRun(chunk, opt.tempDir)
+			if err != nil {
+				return err
+			}
+			runs = append(runs, path)
+		}
+
+		if done {
+			break
+		}
+	}
+
+	return mergeRuns(ctx, opt, runOpt, runs, w)
+}
+
+// mergeRuns merges runs into the output. If there are more than mergeFanIn
+// runs, consecutive runs are merged in batches into new runs first, so merge
+// stays stable. Intermediate merges keep duplicates as runOpt does.
+func mergeRuns(ctx context.Context, opt, runOpt *options, paths []string, w *dataWriter) error {
+	temps := []string{}
+	defer func() { removeFiles(temps) }()
+
+	for len(paths) > mergeFanIn {
+		merged := make([]string, 0, (len(paths)+mergeFanIn-1)/mergeFanIn)
+		for i := 0; i < len(paths); i += mergeFanIn {
+			end := i + mergeFanIn
+			if end > len(paths) {
+				end = len(paths)
+			}
+			if end-i == 1 {
+				merged = append(merged, paths[i])
+				continue
+			}
+
+			path, err := mergeRunsToTemp(ctx, runOpt, paths[i:end], opt.tempDir)
+			if err != nil {
+				return err
+			}
+			temps = append(temps, path)
+			merged = append(merged, path)
+			removeFiles(paths[i:end])
+		}
+		paths = merged
+	}
+
+	return mergeFiles(ctx, opt, paths, w)
+}
+
+// mergeRunsToTemp merges runs into a new temp run and returns its path
+func mergeRunsToTemp(ctx context.Context, opt *options, paths []string, dir string) (string, error) {
+	file, err := os.CreateTemp(dir, tempRunPattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create temp file: %v\n", err)
+		return "", errBadCreateFile
+	}
+	path := file.Name()
+
+	// every line of run ends with new line, so empty lines are kept
+	w := newStreamDataWriter(file)
+	err = mergeFiles(ctx, opt, paths, w)
+	if err == nil {
+		err = w.close()
+	}
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		fmt.Fprintf(os.Stderr, "unable to write to temp file %s: %v\n", path, closeErr)
+		err = errWriteFail
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	return path, nil
+}
+
+func mergeFiles(ctx context.Context, opt *options, paths []string, w *dataWriter) error {
+	readers := make([]io.Reader, 0, len(paths))
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to open file %s: %v\n", path, err)
+			return errBadOpenFile
+		}
+		defer file.Close()
+		readers = append(readers, file)
+	}
+
+	return mergeSorted(ctx, opt, readers, w)
+}
+
+// readChunk reads lines until their approximate size exceeds budget.
+// Returns true if there is nothing left to read.
+func readChunk(scanner lineScanner, budget int64) ([]string, bool, error) {
+	out := make([]string, 0)
+	var size int64
+
+	for size < budget {
+		if !scanner.Scan() {
+			return out, true, scanner.Err()
+		}
+		s := scanner.Text()
+		size += int64(len(s) + lineOverhead)
+		out = append(out, s)
+	}
+
+	return out, false, nil
+}
+
+func writeRun(data []string, dir string) (string, error) {
+	file, err := os.CreateTemp(dir, tempRunPattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create temp file: %v\n", err)
+		return "", errBadCreateFile
+	}
+	path := file.Name()
+
+	w := bufio.NewWriter(file)
+	for _, v := range data {
+		w.WriteString(v)
+		w.WriteByte('\n')
+	}
+
+	err = w.Flush()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		fmt.Fprintf(os.Stderr, "unable to write to temp file %s: %v\n", path, err)
+		return "", errWriteFail
+	}
+
+	return path, nil
+}
+
+func removeFiles(paths []string) {
+	for _, v := range paths {
+		os.Remove(v)
+	}
+}
@@ -0,0 +1,167 @@
+package sort
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalSortMatchesInMemory(t *testing.T) {
+	baseInputData := []string{
+		"fsdsda opsd aso asdpo",
+		"cspds gpfdp psdfd",
+		"zals",
+		"zals",
+		"bbsdfpd ukdsk",
+		"14 zpsd naso bcdpo",
+		"fsdsda gpsd khso fdpo",
+		"3 gpsd",
+		"",
+		"0433",
+	}
+
+	testCases := []struct {
+		name string
+		opt  options
+	}{
+		{
+			name: "whole line asc",
+			opt:  options{keys: []sortKey{newWholeLineKey(sortTypeDefautl, false)}},
+		},
+		{
+			name: "whole line numeric desc",
+			opt:  options{keys: []sortKey{newWholeLineKey(sortTypeNumeric, true)}, isDescOrder: true},
+		},
+		{
+			name: "column asc unique",
+			opt:  options{keys: []sortKey{newFieldKey(1, sortTypeDefautl, false)}, isUniqueOnly: true},
+		},
+		{
+			name: "column asc count",
+			opt:  options{keys: []sortKey{newFieldKey(1, sortTypeDefautl, false)}, isUniqueOnly: true, isCountUnique: true},
+		},
+		{
+			name: "whole line human numeric asc",
+			opt:  options{keys: []sortKey{newWholeLineKey(sortTypeHumanNumeric, false)}},
+		},
+		{
+			name: "column month desc",
+			opt:  options{keys: []sortKey{newFieldKey(1, sortTypeMonth, true)}, isDescOrder: true},
+		},
+		{
+			name: "column stable desc",
+			opt:  options{keys: []sortKey{newFieldKey(1, sortTypeDefautl, true)}, isDescOrder: true, isStable: true},
+		},
+		{
+			name: "column numeric asc",
+			opt:  options{keys: []sortKey{newFieldKey(0, sortTypeNumeric, false)}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, budget := range []int64{1, lineOverhead * 3, 1 << 20} {
+				opt := tc.opt
+				opt.inputs = []input{newStringInput("-", baseInputData)}
+				opt.outFilePath = filepath.Join(dir, "out.txt")
+				opt.memoryBudget = budget
+				opt.tempDir = dir
+
+				expected := sortData(&opt, append([]string{}, baseInputData...))
+				assert.NoError(t, run(context.Background(), &opt))
+
+				res, err := os.ReadFile(opt.outFilePath)
+				assert.NoError(t, err)
+				assert.Equal(t, strings.Join(expected, "\n"), string(res))
+
+				entries, err := os.ReadDir(dir)
+				assert.NoError(t, err)
+				assert.Len(t, entries, 1, "temp files must be removed")
+			}
+		})
+	}
+}
+
+func TestExternalSortMoreRunsThanFanIn(t *testing.T) {
+	// one line per run gives several intermediate merge passes
+	data := []string{"", ""}
+	for i := 0; i < mergeFanIn*mergeFanIn+3; i++ {
+		data = append(data, fmt.Sprintf("%d key%d", (i*7919)%101, i%5))
+	}
+
+	testCases := []struct {
+		name string
+		opt  options
+	}{
+		{
+			name: "numeric asc",
+			opt:  options{keys: []sortKey{newWholeLineKey(sortTypeNumeric, false)}},
+		},
+		{
+			name: "column stable",
+			opt:  options{keys: []sortKey{newFieldKey(1, sortTypeDefautl, false)}, isStable: true},
+		},
+		{
+			name: "column count",
+			opt:  options{keys: []sortKey{newFieldKey(1, sortTypeDefautl, false)}, isUniqueOnly: true, isCountUnique: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opt := tc.opt
+			opt.inputs = []input{newStringInput("-", data)}
+			opt.outFilePath = filepath.Join(dir, "out.txt")
+			opt.memoryBudget = 1
+			opt.tempDir = dir
+
+			expected := sortData(&opt, append([]string{}, data...))
+			assert.NoError(t, run(context.Background(), &opt))
+
+			res, err := os.ReadFile(opt.outFilePath)
+			assert.NoError(t, err)
+			assert.Equal(t, strings.Join(expected, "\n"), string(res))
+
+			entries, err := os.ReadDir(dir)
+			assert.NoError(t, err)
+			assert.Len(t, entries, 1, "temp files must be removed")
+		})
+	}
+}
+
+func TestParseMemorySize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected int64
+		isError  bool
+	}{
+		{name: "no suffix means kibibytes", data: "10", expected: 10 * 1024},
+		{name: "bytes", data: "100b", expected: 100},
+		{name: "megabytes", data: "2M", expected: 2 << 20},
+		{name: "gigabytes", data: "1G", expected: 1 << 30},
+		{name: "empty", data: "", isError: true},
+		{name: "unknown suffix", data: "1X", isError: true},
+		{name: "zero", data: "0K", isError: true},
+		{name: "overflow", data: "99999999999T", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parseMemorySize(tc.data)
+			if tc.isError {
+				assert.ErrorIs(t, err, errInvalidMemorySize)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package sort
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// fieldBounds holds byte offsets of a field in a line
+type fieldBounds struct {
+	start int
+	end   int
+}
+
+// fieldSplitter finds fields of a line. Every line has at least one field.
+type fieldSplitter interface {
+	split(s string) []fieldBounds
+}
+
+// blankSplitter splits line at transitions from non-blank to blank
+// characters. Leading blanks belong to the field, so fields are never empty
+// except the field of an empty line.
+type blankSplitter struct{}
+
+func (blankSplitter) split(s string) []fieldBounds {
+	if s == "" {
+		return []fieldBounds{{}}
+	}
+
+	out := []fieldBounds{}
+	for i := 0; i < len(s); {
+		start := i
+		for i < len(s) && isBlank(s[i]) {
+			i++
+		}
+		for i < len(s) && !isBlank(s[i]) {
+			i++
+		}
+		out = append(out, fieldBounds{start, i})
+	}
+	return out
+}
+
+// runeSplitter splits line at every occurrence of separator, so adjacent
+// separators give empty field and line of n separators has n+1 fields
+type runeSplitter struct {
+	sep string
+}
+
+func newRuneSplitter(sep rune) runeSplitter {
+	return runeSplitter{sep: string(sep)}
+}
+
+func (r runeSplitter) split(s string) []fieldBounds {
+	out := []fieldBounds{}
+	start := 0
+	for {
+		i := strings.Index(s[start:], r.sep)
+		if i < 0 {
+			return append(out, fieldBounds{start, len(s)})
+		}
+		out = append(out, fieldBounds{start, start + i})
+		start += i + len(r.sep)
+	}
+}
+
+// regexpSplitter splits line at every non-empty match of regexp with the
+// same empty field rules as runeSplitter
+type regexpSplitter struct {
+	re *regexp.Regexp
+}
+
+func (r regexpSplitter) split(s string) []fieldBounds {
+	out := []fieldBounds{}
+	start := 0
+	for _, m := range r.re.FindAllStringIndex(s, -1) {
+		if m[0] == m[1] {
+			continue
+		}
+		out = append(out, fieldBounds{start, m[0]})
+		start = m[1]
+	}
+	return append(out, fieldBounds{start, len(s)})
+}
+
+// parseSeparator returns single character separator, "\t" and "\0"
+// escape sequences are supported
+func parseSeparator(s string) (rune, error) {
+	switch s {
+	case `\t`:
+		return '\t', nil
+	case `\0`:
+		return 0, nil
+	}
+
+	if utf8.RuneCountInString(s) != 1 {
+		return 0, errInvalidSeparator
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return 0, errInvalidSeparator
+	}
+	return r, nil
+}
+
+func isBlank(b byte) bool {
+	return b == ' ' || b == '\t'
+}
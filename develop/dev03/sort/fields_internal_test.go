@@ -0,0 +1,162 @@
+package sort
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func splitToStrings(splitter fieldSplitter, s string) []string {
+	out := []string{}
+	for _, f := range splitter.split(s) {
+		out = append(out, s[f.start:f.end])
+	}
+	return out
+}
+
+func TestSplitters(t *testing.T) {
+	testCases := []struct {
+		name     string
+		splitter fieldSplitter
+		data     string
+		expected []string
+	}{
+		{
+			name:     "blank leading blanks belong to field",
+			splitter: blankSplitter{},
+			data:     " 1 03             4 ",
+			expected: []string{" 1", " 03", "             4", " "},
+		},
+		{
+			name:     "blank tabs",
+			splitter: blankSplitter{},
+			data:     "a\t\tb c",
+			expected: []string{"a", "\t\tb", " c"},
+		},
+		{
+			name:     "blank empty line",
+			splitter: blankSplitter{},
+			data:     "",
+			expected: []string{""},
+		},
+		{
+			name:     "comma empty fields",
+			splitter: newRuneSplitter(','),
+			data:     ",a,,b,",
+			expected: []string{"", "a", "", "b", ""},
+		},
+		{
+			name:     "tab keeps spaces",
+			splitter: newRuneSplitter('\t'),
+			data:     "a b\tc d",
+			expected: []string{"a b", "c d"},
+		},
+		{
+			name:     "cyrillic separator",
+			splitter: newRuneSplitter('ж'),
+			data:     "одинждваж",
+			expected: []string{"один", "два", ""},
+		},
+		{
+			name:     "rune empty line",
+			splitter: newRuneSplitter(';'),
+			data:     "",
+			expected: []string{""},
+		},
+		{
+			name:     "regexp",
+			splitter: regexpSplitter{re: regexp.MustCompile(`\s*[,;]\s*`)},
+			data:     "a , b;c;;d",
+			expected: []string{"a", "b", "c", "", "d"},
+		},
+		{
+			name:     "regexp empty matches are ignored",
+			splitter: regexpSplitter{re: regexp.MustCompile(`,*`)},
+			data:     "ab,,c",
+			expected: []string{"ab", "c"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, splitToStrings(tc.splitter, tc.data))
+		})
+	}
+}
+
+func TestParseSeparator(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected rune
+		isError  bool
+	}{
+		{data: ",", expected: ','},
+		{data: "\t", expected: '\t'},
+		{data: `\t`, expected: '\t'},
+		{data: `\0`, expected: 0},
+		{data: "ж", expected: 'ж'},
+		{data: "", isError: true},
+		{data: "ab", isError: true},
+		{data: "\xff", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.data, func(t *testing.T) {
+			res, err := parseSeparator(tc.data)
+			if tc.isError {
+				assert.ErrorIs(t, err, errInvalidSeparator)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestSortWithSeparator(t *testing.T) {
+	baseInputData := []string{
+		"ivan;;30",
+		"anna;moscow;25",
+		"petr;perm",
+		"olga;kazan;41",
+	}
+
+	testCases := []struct {
+		name     string
+		key      sortKey
+		expected []string
+	}{
+		{
+			name: "empty field goes first",
+			key:  newFieldKey(1, sortTypeDefautl, false),
+			expected: []string{
+				"ivan;;30",
+				"olga;kazan;41",
+				"anna;moscow;25",
+				"petr;perm",
+			},
+		},
+		{
+			name: "numeric third field",
+			key:  newFieldKey(2, sortTypeNumeric, false),
+			expected: []string{
+				"petr;perm",
+				"anna;moscow;25",
+				"ivan;;30",
+				"olga;kazan;41",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt := &options{
+				keys:     []sortKey{tc.key},
+				splitter: newRuneSplitter(';'),
+			}
+			d := append([]string{}, baseInputData...)
+			assert.Equal(t, tc.expected, sortData(opt, d))
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package sort
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// input is an opened source of lines
+type input struct {
+	name   string
+	reader io.ReadCloser
+}
+
+// lineScanner reads data line by line
+type lineScanner interface {
+	Scan() bool
+	Text() string
+	Err() error
+}
+
+// openInputs opens files in order, stdinName means standard input
+func openInputs(paths []string) ([]input, error) {
+	out := make([]input, 0, len(paths))
+	for _, path := range paths {
+		reader, err := getReader(path)
+		if err != nil {
+			closeInputs(out)
+			return nil, err
+		}
+		out = append(out, input{name: path, reader: reader})
+	}
+	return out, nil
+}
+
+func getReader(filePath string) (io.ReadCloser, error) {
+	if filePath == stdinName {
+		return os.Stdin, nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to open file %s: %v\n", filePath, err)
+		return nil, errBadOpenFile
+	}
+
+	return file, nil
+}
+
+func closeInputs(inputs []input) {
+	for _, v := range inputs {
+		v.reader.Close()
+	}
+}
+
+// ctxCheckInterval is the number of lines read between checks
+// of context cancellation
+const ctxCheckInterval = 1024
+
+// inputsScanner reads lines of all inputs one after another. Every input is
+// closed as soon as it is read to the end. Scanning stops with error when
+// context is canceled.
+type inputsScanner struct {
+	ctx     context.Context
+	inputs  []input
+	scanner *bufio.Scanner
+	count   int
+	err     error
+}
+
+func newInputsScanner(ctx context.Context, inputs []input) *inputsScanner {
+	return &inputsScanner{ctx: ctx, inputs: inputs}
+}
+
+func (s *inputsScanner) Scan() bool {
+	if s.count++; s.count%ctxCheckInterval == 0 && s.err == nil {
+		s.err = s.ctx.Err()
+	}
+
+	for s.err == nil {
+		if s.scanner == nil {
+			if len(s.inputs) == 0 {
+				return false
+			}
+			s.scanner = bufio.NewScanner(s.inputs[0].reader)
+		}
+
+		if s.scanner.Scan() {
+			return true
+		}
+
+		s.err = s.scanner.Err()
+		s.inputs[0].reader.Close()
+		s.inputs = s.inputs[1:]
+		s.scanner = nil
+	}
+	return false
+}
+
+func (s *inputsScanner) Text() string {
+	return s.scanner.Text()
+}
+
+func (s *inputsScanner) Err() error {
+	return s.err
+}
+
+// Close closes inputs that were not read to the end
+func (s *inputsScanner) Close() {
+	closeInputs(s.inputs)
+	s.inputs = nil
+}
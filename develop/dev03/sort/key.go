@@ -0,0 +1,197 @@
+package sort
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	keyFieldSep      = ","
+	keyCharSep       = "."
+	keyEndOfLine     = -1
+	keyEndOfField    = 0
+	keyModifierChars = "nghMRVrbfdi"
+)
+
+// sortKey describes part of line used for comparison: from start field and
+// char to end field and char. Fields and chars are counted from 0 except
+// endChar which is counted from 1 and keyEndOfField means the whole field.
+type sortKey struct {
+	startField int
+	startChar  int
+	endField   int
+	endChar    int
+
+	sortType              int
+	isDescOrder           bool
+	isIgnoreLeadingBlanks bool
+	isFoldCase            bool
+	isDictionaryOrder     bool
+	isIgnoreNonPrinting   bool
+}
+
+// newWholeLineKey returns key that covers the whole line
+func newWholeLineKey(sortType int, isDescOrder bool) sortKey {
+	return sortKey{
+		endField:    keyEndOfLine,
+		sortType:    sortType,
+		isDescOrder: isDescOrder,
+	}
+}
+
+// parseSortKey parses key definition in format POS1[,POS2] where POS is
+// F[.C][OPTS]: F is field number, C is char position in field and OPTS
+// are one-letter ordering options. Key without options inherits ordering
+// options of the global key.
+func parseSortKey(s string, global sortKey) (sortKey, error) {
+	key := newWholeLineKey(sortTypeDefautl, false)
+	parts := strings.SplitN(s, keyFieldSep, 2)
+
+	startField, startChar, modifiers, err := parseKeyPosition(parts[0], false)
+	if err != nil {
+		return sortKey{}, err
+	}
+	key.startField = startField
+	key.startChar = startChar
+
+	if len(parts) > 1 {
+		endField, endChar, endModifiers, err := parseKeyPosition(parts[1], true)
+		if err != nil {
+			return sortKey{}, err
+		}
+		key.endField = endField
+		key.endChar = endChar
+		modifiers += endModifiers
+	}
+
+	if modifiers == "" {
+		global.startField, global.startChar = key.startField, key.startChar
+		global.endField, global.endChar = key.endField, key.endChar
+		return global, nil
+	}
+
+	if err := key.applyModifiers(modifiers); err != nil {
+		return sortKey{}, err
+	}
+	return key, nil
+}
+
+// parseKeyPosition returns field and char indices and modifiers of a key
+// position. Char 0 is allowed for end position only and means end of field.
+func parseKeyPosition(s string, isEnd bool) (int, int, string, error) {
+	modStart := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	if modStart < 0 {
+		modStart = len(s)
+	}
+	pos, modifiers := s[:modStart], s[modStart:]
+
+	fieldStr, charStr, hasChar := strings.Cut(pos, keyCharSep)
+	field, err := strconv.Atoi(fieldStr)
+	if err != nil {
+		return 0, 0, "", errInvalidSortKey
+	}
+	if field < minSortCol {
+		return 0, 0, "", errInvalidSortCol
+	}
+
+	char := 1
+	if isEnd {
+		char = keyEndOfField
+	}
+	if hasChar {
+		char, err = strconv.Atoi(charStr)
+		if err != nil || char < 0 || (char == 0 && !isEnd) {
+			return 0, 0, "", errInvalidSortKey
+		}
+	}
+
+	if isEnd {
+		return field - 1, char, modifiers, nil
+	}
+	return field - 1, char - 1, modifiers, nil
+}
+
+func (k *sortKey) applyModifiers(modifiers string) error {
+	sortTypeMod := ""
+	for _, r := range modifiers {
+		if !strings.ContainsRune(keyModifierChars, r) {
+			return errInvalidSortKey
+		}
+
+		m := string(r)
+		switch m {
+		case "r":
+			k.isDescOrder = true
+			continue
+		case "b":
+			k.isIgnoreLeadingBlanks = true
+			continue
+		case "f":
+			k.isFoldCase = true
+			continue
+		case "d":
+			k.isDictionaryOrder = true
+			continue
+		case "i":
+			k.isIgnoreNonPrinting = true
+			continue
+		}
+
+		if sortTypeMod != "" && sortTypeMod != m {
+			return incompatibleOptionsErr(sortTypeMod, m)
+		}
+		sortTypeMod = m
+		k.sortType = sortTypeFromFlag(m)
+	}
+	return nil
+}
+
+func (k *sortKey) isWholeLine() bool {
+	return k.startField == 0 && k.startChar == 0 && k.endField == keyEndOfLine && !k.isIgnoreLeadingBlanks
+}
+
+// extract returns part of the line covered by key. Returns false if line
+// doesn't have start field of the key.
+func (k *sortKey) extract(s string, splitter fieldSplitter) (string, bool) {
+	if k.isWholeLine() {
+		return s, true
+	}
+
+	fields := splitter.split(s)
+	if k.startField >= len(fields) {
+		return "", false
+	}
+
+	start := k.position(s, fields[k.startField], k.startChar)
+	end := len(s)
+	if k.endField != keyEndOfLine && k.endField < len(fields) {
+		end = fields[k.endField].end
+		if k.endChar != keyEndOfField {
+			end = k.position(s, fields[k.endField], k.endChar)
+		}
+	}
+
+	if end <= start {
+		return "", true
+	}
+	return s[start:end], true
+}
+
+// position returns byte offset of char with given index in the field.
+// Leading blanks of the field are not counted if key ignores them.
+func (k *sortKey) position(s string, field fieldBounds, char int) int {
+	pos := field.start
+	if k.isIgnoreLeadingBlanks {
+		for pos < field.end && isBlank(s[pos]) {
+			pos++
+		}
+	}
+
+	for ; char > 0 && pos < field.end; char-- {
+		_, size := utf8.DecodeRuneInString(s[pos:field.end])
+		pos += size
+	}
+	return pos
+}
@@ -0,0 +1,213 @@
+package sort
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSortKey(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		global   sortKey
+		expected sortKey
+		isError  bool
+	}{
+		{
+			name:     "field to end of line",
+			data:     "2",
+			expected: sortKey{startField: 1, endField: keyEndOfLine},
+		},
+		{
+			name:     "single field",
+			data:     "2,2",
+			expected: sortKey{startField: 1, endField: 1},
+		},
+		{
+			name:     "chars",
+			data:     "1.2,3.4",
+			expected: sortKey{startChar: 1, endField: 2, endChar: 4},
+		},
+		{
+			name:     "end char 0 means end of field",
+			data:     "1,3.0",
+			expected: sortKey{endField: 2},
+		},
+		{
+			name:     "modifiers",
+			data:     "2,2nr",
+			expected: sortKey{startField: 1, endField: 1, sortType: sortTypeNumeric, isDescOrder: true},
+		},
+		{
+			name:     "modifiers at start position",
+			data:     "3bfr",
+			expected: sortKey{startField: 2, endField: keyEndOfLine, isDescOrder: true, isIgnoreLeadingBlanks: true, isFoldCase: true},
+		},
+		{
+			name:     "dictionary and ignore nonprinting",
+			data:     "2,2di",
+			expected: sortKey{startField: 1, endField: 1, isDictionaryOrder: true, isIgnoreNonPrinting: true},
+		},
+		{
+			name:     "inherit global options without modifiers",
+			data:     "1.2,2",
+			global:   sortKey{endField: keyEndOfLine, sortType: sortTypeMonth, isDescOrder: true, isFoldCase: true},
+			expected: sortKey{startChar: 1, endField: 1, sortType: sortTypeMonth, isDescOrder: true, isFoldCase: true},
+		},
+		{
+			name:     "modifiers override global options",
+			data:     "1h",
+			global:   sortKey{endField: keyEndOfLine, sortType: sortTypeMonth, isDescOrder: true, isFoldCase: true},
+			expected: sortKey{endField: keyEndOfLine, sortType: sortTypeHumanNumeric},
+		},
+		{
+			name:    "random and version modifiers",
+			data:    "2R,3V",
+			isError: true,
+		},
+		{
+			name:     "version modifier",
+			data:     "2,2V",
+			expected: sortKey{startField: 1, endField: 1, sortType: sortTypeVersion},
+		},
+		{name: "field 0", data: "0", isError: true},
+		{name: "start char 0", data: "1.0", isError: true},
+		{name: "empty", data: "", isError: true},
+		{name: "unknown modifier", data: "1x", isError: true},
+		{name: "incompatible modifiers", data: "1n,2M", isError: true},
+		{name: "no field", data: "n", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parseSortKey(tc.data, tc.global)
+			if tc.isError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestSortKeyExtract(t *testing.T) {
+	src := "alpha  beta gamma delta"
+
+	testCases := []struct {
+		name     string
+		key      string
+		expected string
+		isAbsent bool
+	}{
+		{name: "whole line", key: "1", expected: src},
+		{name: "field to end", key: "3", expected: " gamma delta"},
+		{name: "single field", key: "2,2", expected: "  beta"},
+		{name: "single field ignore blanks", key: "2b,2", expected: "beta"},
+		{name: "fields range", key: "2,3", expected: "  beta gamma"},
+		{name: "start char", key: "3.2,3", expected: "gamma"},
+		{name: "start char ignore blanks", key: "3.2b,3", expected: "amma"},
+		{name: "end char", key: "3,3.2", expected: " g"},
+		{name: "start and end chars", key: "1.2,2.3", expected: "lpha  b"},
+		{name: "end beyond last field", key: "4,9", expected: " delta"},
+		{name: "end before start", key: "3,2", expected: ""},
+		{name: "absent field", key: "5", isAbsent: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := parseSortKey(tc.key, sortKey{})
+			assert.NoError(t, err)
+
+			res, ok := key.extract(src, blankSplitter{})
+			assert.Equal(t, !tc.isAbsent, ok)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestSortKeyExtractUnicode(t *testing.T) {
+	key, err := parseSortKey("2.2,2.3", sortKey{})
+	assert.NoError(t, err)
+
+	res, ok := key.extract("город Москва", blankSplitter{})
+	assert.True(t, ok)
+	assert.Equal(t, "Мо", res)
+}
+
+func TestMultiKeySort(t *testing.T) {
+	baseInputData := []string{
+		"bob 25 Mar",
+		"alice 30 jan",
+		"carol 25 Jan",
+		"dave 30 Feb",
+		"Eve 25 mar",
+	}
+
+	testCases := []struct {
+		name     string
+		keys     []string
+		expected []string
+	}{
+		{
+			name: "numeric then string",
+			keys: []string{"2,2n", "1,1"},
+			expected: []string{
+				"Eve 25 mar",
+				"bob 25 Mar",
+				"carol 25 Jan",
+				"alice 30 jan",
+				"dave 30 Feb",
+			},
+		},
+		{
+			name: "numeric desc then month",
+			keys: []string{"2,2nr", "3M"},
+			expected: []string{
+				"alice 30 jan",
+				"dave 30 Feb",
+				"carol 25 Jan",
+				"Eve 25 mar",
+				"bob 25 Mar",
+			},
+		},
+		{
+			name: "fold case",
+			keys: []string{"1,1f"},
+			expected: []string{
+				"alice 30 jan",
+				"bob 25 Mar",
+				"carol 25 Jan",
+				"dave 30 Feb",
+				"Eve 25 mar",
+			},
+		},
+		{
+			name: "char offset",
+			keys: []string{"1.2,1.2", "1r"},
+			expected: []string{
+				"dave 30 Feb",
+				"carol 25 Jan",
+				"alice 30 jan",
+				"bob 25 Mar",
+				"Eve 25 mar",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt := &options{}
+			for _, v := range tc.keys {
+				key, err := parseSortKey(v, sortKey{})
+				assert.NoError(t, err)
+				opt.keys = append(opt.keys, key)
+			}
+
+			d := append([]string{}, baseInputData...)
+			res := sortData(opt, d)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package sort
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"io"
+)
+
+// merge merges inputs that are already sorted without loading them
+// into memory
+func (s *Sorter) merge(ctx context.Context, inputs []input, w *dataWriter) error {
+	readers := make([]io.Reader, len(inputs))
+	for i, v := range inputs {
+		readers[i] = v.reader
+	}
+	return mergeSorted(ctx, s.opt, readers, w)
+}
+
+// mergeSorted writes lines of sorted readers in sorted order.
+// Equal lines are taken in order of readers, with unique option only
+// the first of them is written.
+func mergeSorted(ctx context.Context, opt *options, readers []io.Reader, w *dataWriter) error {
+	h := &runHeap{cmp: newLineComparator(opt)}
+
+	for i, reader := range readers {
+		r := newRunReader(reader, i, h.cmp)
+		ok, err := r.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.items = append(h.items, r)
+		}
+	}
+	heap.Init(h)
+
+	uniq := newUniqueWriter(h.cmp, w, opt.isCountUnique)
+	for count := 1; h.Len() > 0; count++ {
+		if count%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		r := h.items[0]
+		var err error
+		if opt.isUniqueOnly {
+			err = uniq.writeLine(r.line)
+		} else {
+			err = w.writeLine(r.line.text)
+		}
+		if err != nil {
+			return err
+		}
+
+		ok, err := r.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(h, 0)
+			continue
+		}
+		heap.Pop(h)
+	}
+
+	return uniq.flush()
+}
+
+// runReader reads sorted lines of a single run
+type runReader struct {
+	scanner *bufio.Scanner
+	cmp     *lineComparator
+	line    line
+	index   int
+}
+
+func newRunReader(r io.Reader, index int, cmp *lineComparator) *runReader {
+	return &runReader{
+		scanner: bufio.NewScanner(r),
+		cmp:     cmp,
+		index:   index,
+	}
+}
+
+func (r *runReader) next() (bool, error) {
+	if !r.scanner.Scan() {
+		return false, r.scanner.Err()
+	}
+	r.line = r.cmp.parse(r.scanner.Text())
+	return true, nil
+}
+
+// runHeap keeps run readers ordered by their current lines.
+// Equal lines are taken in order of runs, so merge is stable.
+type runHeap struct {
+	items []*runReader
+	cmp   *lineComparator
+}
+
+func (h *runHeap) Len() int { return len(h.items) }
+
+func (h *runHeap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if res := h.cmp.compare(a.line, b.line); res != 0 {
+		return res < 0
+	}
+	return a.index < b.index
+}
+
+func (h *runHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *runHeap) Push(x any) { h.items = append(h.items, x.(*runReader)) }
+
+func (h *runHeap) Pop() any {
+	last := len(h.items) - 1
+	item := h.items[last]
+	h.items = h.items[:last]
+	return item
+}
@@ -0,0 +1,155 @@
+package sort
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFile(t *testing.T, path string, data []string) {
+	t.Helper()
+	err := os.WriteFile(path, []byte(strings.Join(data, "\n")+"\n"), 0o644)
+	assert.NoError(t, err)
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	res, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return string(res)
+}
+
+func TestRunMerge(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     [][]string
+		opt      options
+		expected []string
+	}{
+		{
+			name:     "merge strings",
+			data:     [][]string{{"a", "c", "e"}, {"b", "d"}, {}, {"a", "f"}},
+			opt:      options{keys: []sortKey{newWholeLineKey(sortTypeDefautl, false)}},
+			expected: []string{"a", "a", "b", "c", "d", "e", "f"},
+		},
+		{
+			name:     "merge unique",
+			data:     [][]string{{"a", "c"}, {"a", "b", "c"}},
+			opt:      options{keys: []sortKey{newWholeLineKey(sortTypeDefautl, false)}, isUniqueOnly: true},
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "merge unique by key",
+			data:     [][]string{{"a 1", "b 1", "c 1"}, {"a 2", "c 2"}, {"c 3", "d 3"}},
+			opt:      options{keys: []sortKey{newFieldKey(0, sortTypeDefautl, false)}, isUniqueOnly: true},
+			expected: []string{"a 1", "b 1", "c 1", "d 3"},
+		},
+		{
+			name:     "merge count",
+			data:     [][]string{{"a 1", "b 1", "c 1"}, {"a 2", "c 2"}, {"c 3", "d 3"}},
+			opt:      options{keys: []sortKey{newFieldKey(0, sortTypeDefautl, false)}, isUniqueOnly: true, isCountUnique: true},
+			expected: []string{"      2 a 1", "      1 b 1", "      3 c 1", "      1 d 3"},
+		},
+		{
+			name:     "merge numeric desc",
+			data:     [][]string{{"x 10", "x 2"}, {"y 3", "y 1"}},
+			opt:      options{keys: []sortKey{newFieldKey(1, sortTypeNumeric, true)}, isDescOrder: true},
+			expected: []string{"x 10", "y 3", "x 2", "y 1"},
+		},
+		{
+			name:     "equal keys are taken in order of inputs",
+			data:     [][]string{{"b 1"}, {"a 1"}},
+			opt:      options{keys: []sortKey{newFieldKey(1, sortTypeNumeric, false)}, isStable: true},
+			expected: []string{"b 1", "a 1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt := tc.opt
+			for _, v := range tc.data {
+				opt.inputs = append(opt.inputs, newStringInput("-", v))
+			}
+			opt.isMergeOnly = true
+			opt.outFilePath = filepath.Join(t.TempDir(), "out.txt")
+
+			assert.NoError(t, run(context.Background(), &opt))
+			assert.Equal(t, strings.Join(tc.expected, "\n"), readTestFile(t, opt.outFilePath))
+		})
+	}
+}
+
+func TestExecuteMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	out := filepath.Join(dir, "out.txt")
+
+	testCases := []struct {
+		name     string
+		args     []string
+		outPath  string
+		expected string
+	}{
+		{
+			name:     "concatenate and sort",
+			args:     []string{"-o", out, b, a},
+			outPath:  out,
+			expected: "1\n2\n3\n4\n5",
+		},
+		{
+			name:     "merge",
+			args:     []string{"-m", "-o", out, a, b},
+			outPath:  out,
+			expected: "1\n2\n3\n4\n5",
+		},
+		{
+			name:     "merge into one of inputs",
+			args:     []string{"-m", "-o", a, a, b},
+			outPath:  a,
+			expected: "1\n2\n3\n4\n5",
+		},
+		{
+			name:     "sort into one of inputs",
+			args:     []string{"-r", "-o", b, a, b},
+			outPath:  b,
+			expected: "5\n4\n3\n2\n1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			writeTestFile(t, a, []string{"1", "3", "5"})
+			writeTestFile(t, b, []string{"2", "4"})
+
+			assert.Equal(t, 0, ExecuteCLI(tc.args))
+			assert.Equal(t, tc.expected, readTestFile(t, tc.outPath))
+
+			entries, err := os.ReadDir(dir)
+			assert.NoError(t, err)
+			assert.Len(t, entries, 3, "temp files must be removed")
+		})
+	}
+}
+
+func TestCheckAcceptsOneFile(t *testing.T) {
+	_, err := newOptions([]string{"-c", "a", "b"})
+	assert.ErrorIs(t, err, errCheckExtraOperand)
+}
+
+func TestInputsScanner(t *testing.T) {
+	scanner := newInputsScanner(context.Background(), []input{
+		newStringInput("a", []string{"1", "2"}),
+		newStringInput("b", []string{}),
+		newStringInput("c", []string{"3"}),
+	})
+	defer scanner.Close()
+
+	res, err := readData(scanner)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, res)
+}
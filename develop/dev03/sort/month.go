@@ -0,0 +1,68 @@
+package sort
+
+import (
+	"strings"
+	"unicode"
+)
+
+// monthNamesEn holds lower case names of every month in English
+var monthNamesEn = [12][]string{
+	{"jan", "january"},
+	{"feb", "february"},
+	{"mar", "march"},
+	{"apr", "april"},
+	{"may"},
+	{"jun", "june"},
+	{"jul", "july"},
+	{"aug", "august"},
+	{"sep", "sept", "september"},
+	{"oct", "october"},
+	{"nov", "november"},
+	{"dec", "december"},
+}
+
+// monthNamesRu holds lower case names of every month in Russian
+// including genitive forms used in dates ("5 марта")
+var monthNamesRu = [12][]string{
+	{"янв", "январь", "января"},
+	{"фев", "февр", "февраль", "февраля"},
+	{"мар", "март", "марта"},
+	{"апр", "апрель", "апреля"},
+	{"май", "мая"},
+	{"июн", "июнь", "июня"},
+	{"июл", "июль", "июля"},
+	{"авг", "август", "августа"},
+	{"сен", "сент", "сентябрь", "сентября"},
+	{"окт", "октябрь", "октября"},
+	{"ноя", "нояб", "ноябрь", "ноября"},
+	{"дек", "декабрь", "декабря"},
+}
+
+var monthNumbers = newMonthNumbers(monthNamesEn, monthNamesRu)
+
+func newMonthNumbers(tables ...[12][]string) map[string]int {
+	out := make(map[string]int)
+	for _, table := range tables {
+		for i, names := range table {
+			for _, name := range names {
+				out[name] = i + 1
+			}
+		}
+	}
+	return out
+}
+
+// getMonthFromStringStart returns number of month (January is 1) named by
+// the first word of the string. Leading blanks are ignored.
+func getMonthFromStringStart(s string) (int, error) {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	end := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) })
+	if end >= 0 {
+		s = s[:end]
+	}
+
+	if month, has := monthNumbers[strings.ToLower(s)]; has {
+		return month, nil
+	}
+	return 0, errStrNotStartsWithMonth
+}
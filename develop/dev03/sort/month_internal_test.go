@@ -0,0 +1,129 @@
+package sort
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMonthFromStringStart(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected int
+		isError  bool
+	}{
+		{data: "Jan", expected: 1},
+		{data: "JANUARY 2020", expected: 1},
+		{data: "  feb", expected: 2},
+		{data: "Sept.", expected: 9},
+		{data: "dec,31", expected: 12},
+		{data: "май", expected: 5},
+		{data: "Мая", expected: 5},
+		{data: "5 марта", isError: true},
+		{data: "марта 5", expected: 3},
+		{data: "Сентябрь", expected: 9},
+		{data: "янв.", expected: 1},
+		{data: "", isError: true},
+		{data: "ja", isError: true},
+		{data: "janx", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.data, func(t *testing.T) {
+			res, err := getMonthFromStringStart(tc.data)
+			if tc.isError {
+				assert.ErrorIs(t, err, errStrNotStartsWithMonth)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestDoStringMonthSort(t *testing.T) {
+	baseInputData := []string{
+		"March",
+		"dec",
+		"unknown",
+		"январь",
+		"Feb",
+		"апреля",
+		"",
+	}
+
+	testCases := []struct {
+		name         string
+		data         []string
+		expected     []string
+		isDescending bool
+	}{
+		{
+			name:         "asc",
+			data:         baseInputData,
+			expected:     []string{"", "unknown", "январь", "Feb", "March", "апреля", "dec"},
+			isDescending: false,
+		},
+		{
+			name:         "desc",
+			data:         baseInputData,
+			expected:     []string{"dec", "апреля", "March", "Feb", "январь", "unknown", ""},
+			isDescending: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := append([]string{}, tc.data...)
+			res := sortData(newWholeLineOptions(sortTypeMonth, tc.isDescending), d)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestColumnSortMonth(t *testing.T) {
+	baseInputData := []string{
+		"5 марта 2021",
+		"1 Jan 2021",
+		"7 ??? 2021",
+		"3 December 2020",
+		"2 фев 2021",
+	}
+	testCases := []colSortTestData{
+		{
+			name: "sort 2 col asc",
+			data: baseInputData,
+			expected: []string{
+				"7 ??? 2021",
+				"1 Jan 2021",
+				"2 фев 2021",
+				"5 марта 2021",
+				"3 December 2020",
+			},
+			opt: &options{
+				keys: []sortKey{newFieldKey(1, sortTypeMonth, false)},
+			},
+		},
+		{
+			name: "sort 2 col desc",
+			data: baseInputData,
+			expected: []string{
+				"3 December 2020",
+				"5 марта 2021",
+				"2 фев 2021",
+				"1 Jan 2021",
+				"7 ??? 2021",
+			},
+			opt: &options{
+				keys:        []sortKey{newFieldKey(1, sortTypeMonth, true)},
+				isDescOrder: true,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := append([]string{}, tc.data...)
+			res := sortData(tc.opt, d)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package sort
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	decimalPoint       = '.'
+	thousandsSeparator = ','
+)
+
+// decimalNumber is a number stored as digits, so numbers of any length
+// are compared exactly
+type decimalNumber struct {
+	isNegative bool
+	intPart    string
+	fracPart   string
+}
+
+// getDecimalFromStringStart parses number with optional minus sign,
+// thousands separators and decimal point, e.g. "-1,234.50", from the
+// beginning of the string
+func getDecimalFromStringStart(s string) (decimalNumber, error) {
+	d, _, err := getDecimalPrefix(s)
+	return d, err
+}
+
+// getDecimalPrefix parses number like getDecimalFromStringStart and
+// returns the length of the parsed prefix
+func getDecimalPrefix(s string) (decimalNumber, int, error) {
+	var d decimalNumber
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		d.isNegative = true
+		i++
+	}
+
+	var intPart strings.Builder
+	numDigits := 0
+	for ; i < len(s); i++ {
+		if s[i] == thousandsSeparator && numDigits > 0 && i+1 < len(s) && isASCIIDigit(s[i+1]) {
+			continue
+		}
+		if !isASCIIDigit(s[i]) {
+			break
+		}
+		intPart.WriteByte(s[i])
+		numDigits++
+	}
+
+	fracStart, fracEnd := i, i
+	if i < len(s) && s[i] == decimalPoint {
+		fracStart = i + 1
+		for fracEnd = fracStart; fracEnd < len(s) && isASCIIDigit(s[fracEnd]); fracEnd++ {
+			numDigits++
+		}
+	}
+	if numDigits == 0 {
+		return decimalNumber{}, 0, errStrNotStartsWithNumber
+	}
+
+	d.intPart = strings.TrimLeft(intPart.String(), "0")
+	d.fracPart = strings.TrimRight(s[fracStart:fracEnd], "0")
+	if d.intPart == "" && d.fracPart == "" {
+		d.isNegative = false
+	}
+	return d, fracEnd, nil
+}
+
+func compareDecimals(a, b decimalNumber) int {
+	if a.isNegative != b.isNegative {
+		if a.isNegative {
+			return -1
+		}
+		return 1
+	}
+	return reverseIf(compareAbsDecimals(a, b), a.isNegative)
+}
+
+func compareAbsDecimals(a, b decimalNumber) int {
+	if res := compareOrdered(len(a.intPart), len(b.intPart)); res != 0 {
+		return res
+	}
+	if res := strings.Compare(a.intPart, b.intPart); res != 0 {
+		return res
+	}
+	return strings.Compare(a.fracPart, b.fracPart)
+}
+
+// getHumanNumberFromStringStart parses number like getDecimalFromStringStart
+// with optional SI (K, M, G, ...) or IEC (Ki, Mi, Gi, ...) suffix, e.g.
+// "-1.5K" or "10Mi", from the beginning of the string. Like GNU sort, it
+// returns order of the suffix, so numbers are compared by suffix first and
+// by value then. Order is negative for negative numbers and 0 for zero.
+func getHumanNumberFromStringStart(s string) (int, decimalNumber, error) {
+	d, i, err := getDecimalPrefix(s)
+	if err != nil {
+		return 0, decimalNumber{}, err
+	}
+	if d.intPart == "" && d.fracPart == "" || i >= len(s) {
+		return 0, d, nil
+	}
+
+	order := strings.IndexByte(humanNumberSuffixes, s[i]) + 1
+	if s[i] == 'k' {
+		order = 1
+	}
+	if d.isNegative {
+		order = -order
+	}
+	return order, d, nil
+}
+
+// getGeneralNumberFromStringStart parses floating point number, e.g.
+// "-1.5e3", "inf" or "nan", from the beginning of the string
+func getGeneralNumberFromStringStart(s string) (float64, error) {
+	end := floatPrefixLen(s)
+	if end == 0 {
+		return 0, errStrNotStartsWithNumber
+	}
+
+	n, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil && !isRangeErr(err) {
+		return 0, errStrNotStartsWithNumber
+	}
+	return n, nil
+}
+
+// floatPrefixLen returns length of the longest prefix that is a float
+func floatPrefixLen(s string) int {
+	i := 0
+	if i < len(s) && (s[i] == '-' || s[i] == '+') {
+		i++
+	}
+
+	for _, word := range []string{"infinity", "inf", "nan"} {
+		if len(s)-i >= len(word) && strings.EqualFold(s[i:i+len(word)], word) {
+			return i + len(word)
+		}
+	}
+
+	numDigits := 0
+	for ; i < len(s) && isASCIIDigit(s[i]); i++ {
+		numDigits++
+	}
+	if i < len(s) && s[i] == decimalPoint {
+		for i++; i < len(s) && isASCIIDigit(s[i]); i++ {
+			numDigits++
+		}
+	}
+	if numDigits == 0 {
+		return 0
+	}
+
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '-' || s[j] == '+') {
+			j++
+		}
+		expStart := j
+		for j < len(s) && isASCIIDigit(s[j]) {
+			j++
+		}
+		if j > expStart {
+			i = j
+		}
+	}
+	if s[i-1] == decimalPoint {
+		// "5." is a valid number, but keep the point out for ParseFloat
+		return i - 1
+	}
+	return i
+}
+
+func isRangeErr(err error) bool {
+	numErr, ok := err.(*strconv.NumError)
+	return ok && numErr.Err == strconv.ErrRange
+}
+
+// compareGeneralNumbers compares floats, NaN goes before all numbers
+func compareGeneralNumbers(a, b float64) int {
+	aNaN, bNaN := math.IsNaN(a), math.IsNaN(b)
+	switch {
+	case aNaN && bNaN:
+		return 0
+	case aNaN:
+		return -1
+	case bNaN:
+		return 1
+	}
+	return compareOrdered(a, b)
+}
@@ -0,0 +1,198 @@
+package sort
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDecimalFromStringStart(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected decimalNumber
+		isError  bool
+	}{
+		{data: "42", expected: decimalNumber{intPart: "42"}},
+		{data: "0042abc", expected: decimalNumber{intPart: "42"}},
+		{data: "-5", expected: decimalNumber{isNegative: true, intPart: "5"}},
+		{data: "3.140", expected: decimalNumber{intPart: "3", fracPart: "14"}},
+		{data: ".5", expected: decimalNumber{fracPart: "5"}},
+		{data: "-0.0", expected: decimalNumber{}},
+		{data: "1,234,567.8", expected: decimalNumber{intPart: "1234567", fracPart: "8"}},
+		{data: "1,x", expected: decimalNumber{intPart: "1"}},
+		{data: ",1", isError: true},
+		{data: "1e3", expected: decimalNumber{intPart: "1"}},
+		{data: "123456789012345678901234567890", expected: decimalNumber{intPart: "123456789012345678901234567890"}},
+		{data: "-", isError: true},
+		{data: "+1", isError: true},
+		{data: "abc", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.data, func(t *testing.T) {
+			res, err := getDecimalFromStringStart(tc.data)
+			if tc.isError {
+				assert.ErrorIs(t, err, errStrNotStartsWithNumber)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestGetHumanNumberFromStringStart(t *testing.T) {
+	testCases := []struct {
+		data          string
+		expectedOrder int
+		expected      decimalNumber
+		isError       bool
+	}{
+		{data: "12", expected: decimalNumber{intPart: "12"}},
+		{data: "1.5K", expectedOrder: 1, expected: decimalNumber{intPart: "1", fracPart: "5"}},
+		{data: "10k", expectedOrder: 1, expected: decimalNumber{intPart: "10"}},
+		{data: "10Mi", expectedOrder: 2, expected: decimalNumber{intPart: "10"}},
+		{data: "-3G", expectedOrder: -3, expected: decimalNumber{isNegative: true, intPart: "3"}},
+		{data: "0K", expected: decimalNumber{}},
+		{data: "2Q", expectedOrder: 10, expected: decimalNumber{intPart: "2"}},
+		{data: "5X", expected: decimalNumber{intPart: "5"}},
+		{data: "K", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.data, func(t *testing.T) {
+			order, res, err := getHumanNumberFromStringStart(tc.data)
+			if tc.isError {
+				assert.ErrorIs(t, err, errStrNotStartsWithNumber)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedOrder, order)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestGetGeneralNumberFromStringStart(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected float64
+		isError  bool
+	}{
+		{data: "1e3", expected: 1000},
+		{data: "-2.5E-1x", expected: -0.25},
+		{data: "+7", expected: 7},
+		{data: "5.", expected: 5},
+		{data: ".5", expected: 0.5},
+		{data: "1e", expected: 1},
+		{data: "inf", expected: math.Inf(1)},
+		{data: "-Infinity", expected: math.Inf(-1)},
+		{data: "1e999", expected: math.Inf(1)},
+		{data: "e3", isError: true},
+		{data: "", isError: true},
+		{data: "-", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.data, func(t *testing.T) {
+			res, err := getGeneralNumberFromStringStart(tc.data)
+			if tc.isError {
+				assert.ErrorIs(t, err, errStrNotStartsWithNumber)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+
+	t.Run("nan", func(t *testing.T) {
+		res, err := getGeneralNumberFromStringStart("NaN")
+		assert.NoError(t, err)
+		assert.True(t, math.IsNaN(res))
+	})
+}
+
+func TestNumericSorts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     []string
+		key      sortKey
+		expected []string
+	}{
+		{
+			name:     "numeric signs and decimals",
+			data:     []string{"3.14", "-5", "10", "-10.5", "0", "abc", "2", "-0"},
+			key:      newWholeLineKey(sortTypeNumeric, false),
+			expected: []string{"-10.5", "-5", "-0", "0", "abc", "2", "3.14", "10"},
+		},
+		{
+			name:     "numeric key without number is zero",
+			data:     []string{"-5", "abc", "3", "0"},
+			key:      newWholeLineKey(sortTypeNumeric, false),
+			expected: []string{"-5", "0", "abc", "3"},
+		},
+		{
+			name:     "numeric absent column is zero",
+			data:     []string{"a 1", "b", "c -5"},
+			key:      newFieldKey(1, sortTypeNumeric, false),
+			expected: []string{"c -5", "b", "a 1"},
+		},
+		{
+			name:     "human numeric suffix goes first",
+			data:     []string{"1.0M", "1012K", "4.0K", "980K"},
+			key:      newWholeLineKey(sortTypeHumanNumeric, false),
+			expected: []string{"4.0K", "980K", "1012K", "1.0M"},
+		},
+		{
+			name:     "human numeric du output",
+			data:     []string{"2.0G\tvendor", "900\tREADME.md", "15K\tdocs", "12M\tbuild", "-3K\tweird", "1Ki\tcache"},
+			key:      newWholeLineKey(sortTypeHumanNumeric, false),
+			expected: []string{"-3K\tweird", "900\tREADME.md", "1Ki\tcache", "15K\tdocs", "12M\tbuild", "2.0G\tvendor"},
+		},
+		{
+			name:     "human numeric key without number is zero",
+			data:     []string{"-5K", "abc", "1K"},
+			key:      newWholeLineKey(sortTypeHumanNumeric, false),
+			expected: []string{"-5K", "abc", "1K"},
+		},
+		{
+			name:     "numeric thousands separators",
+			data:     []string{"1,000", "999", "12,345.6", "1,000.5"},
+			key:      newWholeLineKey(sortTypeNumeric, false),
+			expected: []string{"999", "1,000", "1,000.5", "12,345.6"},
+		},
+		{
+			name:     "numeric long digit runs",
+			data:     []string{"99999999999999999999", "100000000000000000000", "-99999999999999999999", "-100000000000000000000"},
+			key:      newWholeLineKey(sortTypeNumeric, false),
+			expected: []string{"-100000000000000000000", "-99999999999999999999", "99999999999999999999", "100000000000000000000"},
+		},
+		{
+			name:     "general numeric",
+			data:     []string{"1e3", "inf", "-inf", "nan", "abc", "-2.5", "999", "1.5E2"},
+			key:      newWholeLineKey(sortTypeGeneralNumeric, false),
+			expected: []string{"abc", "nan", "-inf", "-2.5", "1.5E2", "999", "1e3", "inf"},
+		},
+		{
+			name:     "general numeric column",
+			data:     []string{"a 1e-3", "b -1e3", "c 2"},
+			key:      newFieldKey(1, sortTypeGeneralNumeric, false),
+			expected: []string{"b -1e3", "a 1e-3", "c 2"},
+		},
+		{
+			name:     "numeric column with blanks",
+			data:     []string{"a   -3", "b 2", "c -10"},
+			key:      newFieldKey(1, sortTypeNumeric, false),
+			expected: []string{"c -10", "a   -3", "b 2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt := &options{keys: []sortKey{tc.key}}
+			d := append([]string{}, tc.data...)
+			assert.Equal(t, tc.expected, sortData(opt, d))
+		})
+	}
+}
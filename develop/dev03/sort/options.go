@@ -3,24 +3,32 @@ package sort
 import (
 	"flag"
 	"fmt"
-	"io"
 	"os"
+	"strings"
 )
 
 const (
-	defaultSeparator = " "
-
-	minSortCol         = 1
-	sortColDisabledVal = -1
-
-	numericSortFlag      = "n"
-	numericHumanSortFlag = "h"
-	monthNameSortFlag    = "M"
-
-	sortTypeDefautl      = 0
-	sortTypeNumeric      = 1
-	sortTypeHumanNumeric = 2
-	sortTypeMonth        = 3
+	stdinName = "-"
+
+	minSortCol = 1
+
+	numericSortFlag        = "n"
+	numericHumanSortFlag   = "h"
+	monthNameSortFlag      = "M"
+	numericGeneralSortFlag = "g"
+	randomSortFlag         = "R"
+	versionSortFlag        = "V"
+
+	sortTypeDefautl        = 0
+	sortTypeNumeric        = 1
+	sortTypeHumanNumeric   = 2
+	sortTypeMonth          = 3
+	sortTypeGeneralNumeric = 4
+	sortTypeRandom         = 5
+	sortTypeVersion        = 6
+
+	memorySizeSuffixes  = "bKMGT"
+	humanNumberSuffixes = "KMGTPEZYRQ"
 )
 
 type flagOpt struct {
@@ -28,139 +36,182 @@ type flagOpt struct {
 	name  string
 }
 
+// stringsFlag collects values of a flag that can be set several times
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, " ")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// optionsRaw holds values of command line flags
 type optionsRaw struct {
-	sortCol          int
-	isNumericSort    bool
-	isNumericSufSort bool
-	isDescOrder      bool
-	isMonthNameSort  bool
-	isIgnoreTailBsp  bool
-	isCheckIfSorted  bool
-	isUniqueOnly     bool
+	Options
+	isIgnoreTailBsp bool
+	isCheckIfSorted bool
+	isCheckQuiet    bool
+	isMergeOnly     bool
+	memorySize      string
+	randomSource    string
+	outFilePath     string
 }
 
+// options are compiled options of Sorter and options of command line only
 type options struct {
-	sortColIndex    int
-	sortColEnabled  bool
+	keys            []sortKey
 	sortType        int
 	isDescOrder     bool
 	isCheckIfSorted bool
+	isCheckQuiet    bool
 	isUniqueOnly    bool
+	isCountUnique   bool
+	isStable        bool
+	isMergeOnly     bool
 	isIgnoreTailBsp bool
-	separator       string
-	reader          io.ReadCloser
+	locale          string
+	randomSalt      []byte
+	parallel        int
+	splitter        fieldSplitter
+	inputs          []input
 	outFilePath     string
+	isOutputInInput bool
+	memoryBudget    int64
+	tempDir         string
 }
 
 func newOptions(args []string) (*options, error) {
 	optRaw := &optionsRaw{}
 	fs := flag.NewFlagSet("flag", flag.ContinueOnError)
-	fs.IntVar(&optRaw.sortCol, "k", minSortCol, "number of column to sort")
-	fs.BoolVar(&optRaw.isNumericSort, numericSortFlag, false, "numeric sort")
-	// fs.BoolVar(&optRaw.isNumericSufSort, numericHumanSortFlag, false, "numeric sort with suffix")
-	// fs.BoolVar(&optRaw.isMonthNameSort, monthNameSortFlag, false, "sort by month name")
-	fs.BoolVar(&optRaw.isDescOrder, "r", false, "sort in descending order")
-	fs.BoolVar(&optRaw.isUniqueOnly, "u", false, "preserve only unique strings")
+	fs.Var((*stringsFlag)(&optRaw.Keys), "k", "sort by key `POS1[,POS2][OPTS]`, can be repeated")
+	fs.BoolVar(&optRaw.Numeric, numericSortFlag, false, "numeric sort")
+	fs.BoolVar(&optRaw.HumanNumeric, numericHumanSortFlag, false, "numeric sort with suffix")
+	fs.BoolVar(&optRaw.Month, monthNameSortFlag, false, "sort by month name")
+	fs.BoolVar(&optRaw.GeneralNumeric, numericGeneralSortFlag, false, "sort by general numeric value, e.g. -1.5e3, inf, nan")
+	fs.BoolVar(&optRaw.Random, randomSortFlag, false, "shuffle, but group lines with equal keys")
+	fs.StringVar(&optRaw.randomSource, "random-source", "", "get random bytes from `FILE`")
+	fs.BoolVar(&optRaw.Version, versionSortFlag, false, "natural sort of version numbers within text")
+	fs.BoolVar(&optRaw.Reverse, "r", false, "sort in descending order")
+	fs.BoolVar(&optRaw.Unique, "u", false, "preserve only the first of lines with equal keys")
+	fs.BoolVar(&optRaw.Count, "count", false, "like -u, prefix lines by number of lines with equal keys")
+	fs.BoolVar(&optRaw.Stable, "s", false, "stabilize sort by disabling last-resort comparison")
+	fs.BoolVar(&optRaw.FoldCase, "f", false, "fold lower case to upper case characters")
+	fs.BoolVar(&optRaw.Dictionary, "d", false, "consider only blanks, letters and digits")
+	fs.BoolVar(&optRaw.IgnoreNonPrinting, "i", false, "consider only printable characters")
+	fs.StringVar(&optRaw.Locale, "locale", "", "compare strings by collation rules of `LOCALE`, e.g. ru or en")
 	// fs.BoolVar(&optRaw.isIgnoreTailBsp, "b", false, "ignore tail spaces")
-	// fs.BoolVar(&optRaw.isCheckIfSorted, "c", false, "check if data is sorted")
+	fs.BoolVar(&optRaw.isCheckIfSorted, "c", false, "check if data is sorted, report first disorder")
+	fs.BoolVar(&optRaw.isCheckQuiet, "C", false, "check if data is sorted, do not report first disorder")
+	fs.StringVar(&optRaw.Separator, "t", "", "use `SEP` instead of non-blank to blank transition to separate fields")
+	fs.StringVar(&optRaw.SeparatorRegexp, "separator-regexp", "", "separate fields by matches of `REGEXP`")
+	fs.BoolVar(&optRaw.isMergeOnly, "m", false, "merge already sorted files, do not sort")
+	fs.StringVar(&optRaw.outFilePath, "o", "", "write result to `FILE` instead of standard output")
+	fs.IntVar(&optRaw.Parallel, "parallel", 1, "sort using `N` goroutines")
+	fs.StringVar(&optRaw.memorySize, "S", "", "use SIZE of memory for data and spill the rest to temp files")
+	fs.StringVar(&optRaw.TempDir, "T", os.TempDir(), "use DIR for temp files")
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
 	}
 
-	sortCol, sortColEnabled, err := optRaw.getSortColumn()
+	if optRaw.Parallel < 1 {
+		return nil, errInvalidParallel
+	}
+
+	memoryBudget, err := optRaw.getMemoryBudget()
 	if err != nil {
 		return nil, err
 	}
+	optRaw.MemoryBudget = memoryBudget
 
-	if err := optRaw.validateIncompatibleOptions(); err != nil {
-		return nil, err
+	if optRaw.randomSource != "" {
+		file, err := openRandomSource(optRaw.randomSource)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		optRaw.RandomSource = file
 	}
 
-	reader, err := getReader(fs.Arg(0))
+	opt, err := optRaw.compile()
 	if err != nil {
 		return nil, err
 	}
 
-	outFilePath := fs.Arg(1)
-
-	opt := &options{
-		sortColIndex:    sortCol,
-		sortColEnabled:  sortColEnabled,
-		sortType:        optRaw.getSortType(),
-		isDescOrder:     optRaw.isDescOrder,
-		isCheckIfSorted: optRaw.isCheckIfSorted,
-		isUniqueOnly:    optRaw.isUniqueOnly,
-		isIgnoreTailBsp: optRaw.isIgnoreTailBsp,
-		separator:       defaultSeparator,
-		reader:          reader,
-		outFilePath:     outFilePath,
-	}
-
-	return opt, nil
-}
-
-// returns real sort col (first means 0)
-func (o *optionsRaw) getSortColumn() (int, bool, error) {
-	if o.sortCol == sortColDisabledVal {
-		return sortColDisabledVal, false, nil
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{stdinName}
 	}
-	if o.sortCol < minSortCol {
-		return 0, false, errInvalidSortCol
+	if (optRaw.isCheckIfSorted || optRaw.isCheckQuiet) && len(paths) > 1 {
+		return nil, errCheckExtraOperand
 	}
-	return o.sortCol - 1, true, nil
-}
 
-func (o *optionsRaw) validateIncompatibleOptions() error {
-	incompatible := []flagOpt{
-		{o.isNumericSort, numericSortFlag},
-		{o.isNumericSufSort, numericHumanSortFlag},
-		{o.isMonthNameSort, monthNameSortFlag},
+	inputs, err := openInputs(paths)
+	if err != nil {
+		return nil, err
 	}
 
-	name1 := ""
-	for _, f := range incompatible {
-		if f.value {
-			if name1 == "" {
-				name1 = f.name
-				continue
-			}
-			return o.incompatibleOptionsErr(name1, f.name)
-		}
-	}
+	opt.isCheckIfSorted = optRaw.isCheckIfSorted || optRaw.isCheckQuiet
+	opt.isCheckQuiet = optRaw.isCheckQuiet
+	opt.isMergeOnly = optRaw.isMergeOnly
+	opt.isIgnoreTailBsp = optRaw.isIgnoreTailBsp
+	opt.inputs = inputs
+	opt.outFilePath = optRaw.outFilePath
+	opt.isOutputInInput = isOutputInInput(optRaw.outFilePath, paths)
 
-	return nil
+	return opt, nil
 }
 
-func (o *optionsRaw) getSortType() int {
-	if o.isNumericSort {
-		return sortTypeNumeric
+// returns 0 if data should be sorted in memory entirely
+func (o *optionsRaw) getMemoryBudget() (int64, error) {
+	if o.memorySize == "" {
+		return 0, nil
 	}
-	if o.isNumericSufSort {
+	return parseMemorySize(o.memorySize)
+}
+
+func sortTypeFromFlag(name string) int {
+	switch name {
+	case numericSortFlag:
+		return sortTypeNumeric
+	case numericHumanSortFlag:
 		return sortTypeHumanNumeric
-	}
-	if o.isMonthNameSort {
+	case monthNameSortFlag:
 		return sortTypeMonth
+	case numericGeneralSortFlag:
+		return sortTypeGeneralNumeric
+	case randomSortFlag:
+		return sortTypeRandom
+	case versionSortFlag:
+		return sortTypeVersion
 	}
 	return sortTypeDefautl
 }
 
-func getReader(filePath string) (io.ReadCloser, error) {
-	if stat, err := os.Stdin.Stat(); err == nil {
-		if (stat.Mode() & os.ModeCharDevice) == 0 {
-			return os.Stdin, nil
-		}
+// isOutputInInput reports whether output file is one of input files
+func isOutputInInput(outFilePath string, paths []string) bool {
+	if outFilePath == "" {
+		return false
 	}
-
-	file, err := os.Open(filePath)
+	out, err := os.Stat(outFilePath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "unable to open file %s: %v\n", filePath, err)
-		return nil, errBadOpenFile
+		return false
 	}
 
-	return file, nil
+	for _, path := range paths {
+		if path == stdinName {
+			continue
+		}
+		if in, err := os.Stat(path); err == nil && os.SameFile(in, out) {
+			return true
+		}
+	}
+	return false
 }
 
-func (o *optionsRaw) incompatibleOptionsErr(a, b string) error {
+func incompatibleOptionsErr(a, b string) error {
 	return fmt.Errorf("sort: options are incompatible: %s, %s", a, b)
 }
@@ -0,0 +1,100 @@
+package sort
+
+import "sync"
+
+// minParallelPartSize is the least number of lines worth sorting
+// in a separate goroutine
+const minParallelPartSize = 1024
+
+// lineRange holds bounds of a part of lines
+type lineRange struct {
+	start int
+	end   int
+}
+
+// sortParallel splits lines into parts, parses and sorts every part in its
+// own goroutine and then merges sorted parts. Equal lines of different parts
+// keep their order, so result is the same as of sequential sorting.
+func sortParallel(opt *options, data []string, numParts int) []line {
+	parts := splitIntoParts(len(data), numParts)
+	lines := make([]line, len(data))
+
+	var wg sync.WaitGroup
+	for _, p := range parts {
+		wg.Add(1)
+		go func(p lineRange) {
+			defer wg.Done()
+			// comparator parses lines with its own collator
+			cmp := newLineComparator(opt)
+			for i := p.start; i < p.end; i++ {
+				lines[i] = cmp.parse(data[i])
+			}
+			sortLines(cmp, lines[p.start:p.end])
+		}(p)
+	}
+	wg.Wait()
+
+	cmp := newLineComparator(opt)
+	buf := make([]line, len(lines))
+	for len(parts) > 1 {
+		merged := make([]lineRange, 0, (len(parts)+1)/2)
+		for i := 0; i < len(parts); i += 2 {
+			if i+1 == len(parts) {
+				copy(buf[parts[i].start:parts[i].end], lines[parts[i].start:parts[i].end])
+				merged = append(merged, parts[i])
+				continue
+			}
+
+			left, right := parts[i], parts[i+1]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				mergeLines(cmp, buf[left.start:right.end], lines[left.start:left.end], lines[right.start:right.end])
+			}()
+			merged = append(merged, lineRange{left.start, right.end})
+		}
+		wg.Wait()
+
+		lines, buf = buf, lines
+		parts = merged
+	}
+
+	return lines
+}
+
+// splitIntoParts splits n lines into at most numParts parts of almost
+// equal size that are not less than minParallelPartSize
+func splitIntoParts(n, numParts int) []lineRange {
+	if maxParts := n / minParallelPartSize; numParts > maxParts {
+		numParts = maxParts
+	}
+	if numParts < 1 {
+		numParts = 1
+	}
+
+	out := make([]lineRange, 0, numParts)
+	start := 0
+	for i := 0; i < numParts; i++ {
+		end := start + (n-start)/(numParts-i)
+		out = append(out, lineRange{start, end})
+		start = end
+	}
+	return out
+}
+
+// mergeLines merges sorted a and b into dst, equal lines of a go first
+func mergeLines(cmp *lineComparator, dst, a, b []line) {
+	i, j, k := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if cmp.compare(b[j], a[i]) < 0 {
+			dst[k] = b[j]
+			j++
+		} else {
+			dst[k] = a[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], a[i:])
+	copy(dst[k:], b[j:])
+}
@@ -0,0 +1,96 @@
+package sort
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitIntoParts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		n        int
+		numParts int
+		expected []lineRange
+	}{
+		{
+			name:     "too few lines",
+			n:        minParallelPartSize - 1,
+			numParts: 4,
+			expected: []lineRange{{0, minParallelPartSize - 1}},
+		},
+		{
+			name:     "limited by part size",
+			n:        minParallelPartSize * 2,
+			numParts: 4,
+			expected: []lineRange{{0, minParallelPartSize}, {minParallelPartSize, minParallelPartSize * 2}},
+		},
+		{
+			name:     "almost equal parts",
+			n:        minParallelPartSize*3 + 2,
+			numParts: 3,
+			expected: []lineRange{
+				{0, minParallelPartSize},
+				{minParallelPartSize, minParallelPartSize*2 + 1},
+				{minParallelPartSize*2 + 1, minParallelPartSize*3 + 2},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, splitIntoParts(tc.n, tc.numParts))
+		})
+	}
+}
+
+func TestParallelSortMatchesSequential(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	words := []string{"alpha", "Beta", "ёлка", "елка", "gamma", "10K", "2M", "jan", "Feb", ""}
+	data := make([]string, 10000)
+	for i := range data {
+		data[i] = fmt.Sprintf("%s %d %s", words[rnd.Intn(len(words))], rnd.Intn(100), words[rnd.Intn(len(words))])
+	}
+
+	testCases := []struct {
+		name string
+		opt  options
+	}{
+		{
+			name: "whole line",
+			opt:  options{keys: []sortKey{newWholeLineKey(sortTypeDefautl, false)}},
+		},
+		{
+			name: "numeric key desc",
+			opt:  options{keys: []sortKey{newFieldKey(1, sortTypeNumeric, true)}, isDescOrder: true},
+		},
+		{
+			name: "stable numeric key",
+			opt:  options{keys: []sortKey{newFieldKey(1, sortTypeNumeric, false)}, isStable: true},
+		},
+		{
+			name: "stable reverse human numeric key",
+			opt:  options{keys: []sortKey{newFieldKey(2, sortTypeHumanNumeric, true)}, isStable: true},
+		},
+		{
+			name: "locale with unique",
+			opt:  options{keys: []sortKey{newWholeLineKey(sortTypeDefautl, false)}, locale: "ru", isUniqueOnly: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			seqOpt := tc.opt
+			expected := sortData(&seqOpt, append([]string{}, data...))
+
+			for _, n := range []int{2, 3, 8} {
+				opt := tc.opt
+				opt.parallel = n
+				res := sortData(&opt, append([]string{}, data...))
+				assert.Equal(t, expected, res, "parallel %d", n)
+			}
+		})
+	}
+}
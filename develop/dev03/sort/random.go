@@ -0,0 +1,56 @@
+package sort
+
+import (
+	"crypto/rand"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+)
+
+// randomSaltSize is the number of bytes that define random order
+const randomSaltSize = 16
+
+// openRandomSource opens file to read random bytes from
+func openRandomSource(path string) (*os.File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to open file %s: %v\n", path, err)
+		return nil, errBadOpenFile
+	}
+	return file, nil
+}
+
+// newRandomSalt reads salt from the reader or from system random source
+// if reader is nil. The same salt gives the same order of lines.
+func newRandomSalt(r io.Reader) ([]byte, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	salt := make([]byte, randomSaltSize)
+	if _, err := io.ReadFull(r, salt); err != nil {
+		return nil, errInvalidRandomSource
+	}
+	return salt, nil
+}
+
+// randomHash returns hash of the string that depends on the salt, so equal
+// strings have equal hashes, but order of hashes is random
+func randomHash(salt []byte, s string) uint64 {
+	h := fnv.New64a()
+	h.Write(salt)
+	io.WriteString(h, s)
+	return mixHash(h.Sum64())
+}
+
+// mixHash spreads bits of the hash, so similar strings don't get hashes
+// that are close to each other (murmur3 finalizer)
+func mixHash(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
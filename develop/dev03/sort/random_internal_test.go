@@ -0,0 +1,66 @@
+package sort
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomSort(t *testing.T) {
+	data := []string{}
+	for i := 0; i < 50; i++ {
+		data = append(data, "a", "B", "b", "c", "d", "e", "f")
+	}
+
+	newOpt := func(salt string, isFoldCase bool) *options {
+		key := newWholeLineKey(sortTypeRandom, false)
+		key.isFoldCase = isFoldCase
+		return &options{keys: []sortKey{key}, randomSalt: []byte(salt)}
+	}
+
+	t.Run("equal keys are grouped", func(t *testing.T) {
+		res := sortData(newOpt("salt", false), append([]string{}, data...))
+		assert.Equal(t, 7, countGroups(res))
+	})
+
+	t.Run("equal keys with fold case are grouped", func(t *testing.T) {
+		res := sortData(newOpt("salt", true), append([]string{}, data...))
+		assert.Equal(t, 6, countGroups(strings.Split(strings.ToLower(strings.Join(res, "\n")), "\n")))
+	})
+
+	t.Run("same salt gives same order", func(t *testing.T) {
+		a := sortData(newOpt("salt", false), append([]string{}, data...))
+		b := sortData(newOpt("salt", false), append([]string{}, data...))
+		assert.Equal(t, a, b)
+	})
+
+	t.Run("different salt gives different order", func(t *testing.T) {
+		orders := map[string]struct{}{}
+		for _, salt := range []string{"1", "2", "3", "4", "5"} {
+			res := sortData(newOpt(salt, false), append([]string{}, data...))
+			orders[strings.Join(res, "")] = struct{}{}
+		}
+		assert.Greater(t, len(orders), 1)
+	})
+}
+
+// countGroups returns number of runs of equal strings
+func countGroups(data []string) int {
+	n := 0
+	for i := range data {
+		if i == 0 || data[i] != data[i-1] {
+			n++
+		}
+	}
+	return n
+}
+
+func TestReadRandomSalt(t *testing.T) {
+	salt, err := newRandomSalt(strings.NewReader(strings.Repeat("x", randomSaltSize+1)))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(strings.Repeat("x", randomSaltSize)), salt)
+
+	_, err = newRandomSalt(strings.NewReader("short"))
+	assert.ErrorIs(t, err, errInvalidRandomSource)
+}
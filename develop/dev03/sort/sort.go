@@ -1,11 +1,12 @@
 package sort
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"sort"
-
-	"golang.org/x/exp/constraints"
 )
 
 //ExecuteCLI executes sort command
@@ -16,142 +17,95 @@ func ExecuteCLI(args []string) int {
 		return 2
 	}
 
-	if err := run(opt); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+	// interrupted sort removes its temp files
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, opt); err != nil {
+		if !errors.Is(err, errDisorder) {
+			fmt.Fprintln(os.Stderr, err)
+		}
 		return 1
 	}
 
 	return 0
 }
 
-func run(opt *options) error {
-	outData := make([]string, 0)
-	data, err := readData(opt.reader)
-	if err != nil {
-		return err
-	}
+func run(ctx context.Context, opt *options) error {
+	defer closeInputs(opt.inputs)
+	s := &Sorter{opt: opt}
 
-	if opt.isUniqueOnly {
-		data = removeDuplicates(data)
+	if opt.isCheckIfSorted {
+		return runCheck(ctx, s, opt)
 	}
 
-	if opt.sortColEnabled {
-		outData = doColumnSort(opt, data)
-	} else {
-		isDesc := opt.isDescOrder
-		switch opt.sortType {
-		case sortTypeDefautl:
-			outData = doDefaultStringSort(data, isDesc)
-		case sortTypeNumeric:
-			outData = doStringNumericSort(data, isDesc)
-		}
+	newWriter := newDataWriter
+	if opt.isOutputInInput {
+		newWriter = newReplacingDataWriter
 	}
-
-	return writeData(outData, opt.outFilePath)
-}
-
-func doColumnSort(opt *options, data []string) []string {
-	sep := opt.separator
-	colIndex := opt.sortColIndex
-	sortType := opt.sortType
-	isDescending := opt.isDescOrder
-
-	src := append([]string{}, data...)
-	def := []string{}
-	colStr := []columnSortItem[string]{}
-	colInt := []columnSortItem[int]{}
-
-	for i, v := range data {
-		sp := splitStringAndCleanUp(v, sep)
-		if colIndex >= len(sp) {
-			def = append(def, v)
-			continue
-		}
-
-		chunk := sp[colIndex]
-		switch sortType {
-		case sortTypeDefautl:
-			colStr = append(colStr, newColumnSortItem(chunk, i))
-		case sortTypeNumeric:
-			if startsWithDigit(chunk) {
-				d, _ := getNumberFromStringStart(chunk)
-				colInt = append(colInt, newColumnSortItem(d, i))
-				continue
-			}
-			def = append(def, v)
-		default:
-			panic("unknown sort type")
-		}
+	w, err := newWriter(opt.outFilePath)
+	if err != nil {
+		return err
 	}
+	defer w.abort()
 
-	switch sortType {
-	case sortTypeDefautl:
-		return sortAndMergeDefaultAndCol(src, def, colStr, isDescending)
-	case sortTypeNumeric:
-		return sortAndMergeDefaultAndCol(src, def, colInt, isDescending)
+	if opt.isMergeOnly {
+		err = s.merge(ctx, opt.inputs, w)
+	} else {
+		err = s.sort(ctx, opt.inputs, w)
 	}
-	panic("unknown sort type")
-}
-
-func doDefaultStringSort(data []string, isDescending bool) []string {
-	less := func(i, j int) bool { return data[i] < data[j] }
-	if isDescending {
-		sort.Slice(data, reverse(less))
-		return data
+	if err != nil {
+		return err
 	}
-	sort.Slice(data, less)
-	return data
+	return w.close()
 }
 
-func doStringNumericSort(data []string, isDescending bool) []string {
-	src := append([]string{}, data...)
-	noNumeric := []string{}
-	numeric := []columnSortItem[int]{}
+// sort sorts lines of inputs in memory or, if data doesn't fit into memory
+// budget, in temp files
+func (s *Sorter) sort(ctx context.Context, inputs []input, w *dataWriter) error {
+	scanner := newInputsScanner(ctx, inputs)
+	defer scanner.Close()
 
-	for i, v := range data {
-		if startsWithDigit(v) {
-			d, _ := getNumberFromStringStart(v)
-			numeric = append(numeric, newColumnSortItem(d, i))
-			continue
-		}
-		noNumeric = append(noNumeric, v)
+	if s.opt.memoryBudget > 0 {
+		return sortExternal(ctx, s.opt, scanner, w)
 	}
 
-	return sortAndMergeDefaultAndCol(src, noNumeric, numeric, isDescending)
-}
-
-func getSourceStringFromColumnItems[T constraints.Ordered](src []string, items []columnSortItem[T]) []string {
-	out := make([]string, len(items))
-	for i, v := range items {
-		out[i] = src[v.index]
+	data, err := readData(scanner)
+	if err != nil {
+		return err
 	}
-	return out
+	return writeLines(sortData(s.opt, data), w)
 }
 
-func sortCol[T constraints.Ordered](data []columnSortItem[T], isDescending bool) {
-	less := func(i, j int) bool { return data[i].data < data[j].data }
-	if isDescending {
-		sort.Slice(data, reverse(less))
-		return
+// sortData returns sorted data. With unique option only the first line of
+// each run of lines with equal keys is kept.
+func sortData(opt *options, data []string) []string {
+	cmp := newLineComparator(opt)
+	var lines []line
+	if opt.parallel > 1 {
+		lines = sortParallel(opt, data, opt.parallel)
+	} else {
+		lines = cmp.parseAll(data)
+		sortLines(cmp, lines)
 	}
-	sort.Slice(data, less)
-}
-
-func sortAndMergeDefaultAndCol[T constraints.Ordered](src, def []string, col []columnSortItem[T], isDescending bool) []string {
-	doDefaultStringSort(def, isDescending)
-	sortCol(col, isDescending)
 
-	var a1 = def
-	var a2 = getSourceStringFromColumnItems(src, col)
+	if opt.isUniqueOnly {
+		return uniqueLines(cmp, lines, opt.isCountUnique)
+	}
 
-	if isDescending {
-		a1, a2 = a2, a1
+	for i, v := range lines {
+		data[i] = v.text
 	}
-	return append(a1, a2...)
+	return data
 }
 
-func reverse(less func(i, j int) bool) func(i, j int) bool {
-	return func(i, j int) bool {
-		return !less(i, j)
+// sortLines sorts lines, sort is stable if comparator doesn't use
+// last-resort comparison
+func sortLines(cmp *lineComparator, lines []line) {
+	less := func(i, j int) bool { return cmp.compare(lines[i], lines[j]) < 0 }
+	if cmp.isStable {
+		sort.SliceStable(lines, less)
+		return
 	}
+	sort.Slice(lines, less)
 }
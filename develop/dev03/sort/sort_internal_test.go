@@ -1,11 +1,38 @@
 package sort
 
 import (
+	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
+func newStringInput(name string, data []string) input {
+	return input{
+		name:   name,
+		reader: io.NopCloser(strings.NewReader(strings.Join(data, "\n"))),
+	}
+}
+
+func newWholeLineOptions(sortType int, isDescending bool) *options {
+	return &options{
+		keys:        []sortKey{newWholeLineKey(sortType, isDescending)},
+		isDescOrder: isDescending,
+	}
+}
+
+// newFieldKey returns key that covers single field
+func newFieldKey(field, sortType int, isDescending bool) sortKey {
+	return sortKey{
+		startField:  field,
+		endField:    field,
+		sortType:    sortType,
+		isDescOrder: isDescending,
+	}
+}
+
 type colSortTestData struct {
 	name     string
 	data     []string
@@ -72,7 +99,7 @@ func TestDoStingSort(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			d := append([]string{}, tc.data...)
-			res := doDefaultStringSort(d, tc.isDescending)
+			res := sortData(newWholeLineOptions(sortTypeDefautl, tc.isDescending), d)
 			assert.Equal(t, tc.expected, res)
 		})
 	}
@@ -127,7 +154,7 @@ func TestDoNumericStingSort(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			d := append([]string{}, tc.data...)
-			res := doStringNumericSort(d, tc.isDescending)
+			res := sortData(newWholeLineOptions(sortTypeNumeric, tc.isDescending), d)
 			assert.Equal(t, tc.expected, res)
 		})
 	}
@@ -155,9 +182,7 @@ func TestColumnSortString(t *testing.T) {
 				"fsdsda gpsd khso fdpo",
 			},
 			opt: &options{
-				isDescOrder:  false,
-				sortColIndex: 3,
-				separator:    defaultSeparator,
+				keys: []sortKey{newFieldKey(3, sortTypeDefautl, false)},
 			},
 		},
 		{
@@ -172,9 +197,8 @@ func TestColumnSortString(t *testing.T) {
 				"bbsdfpd ukdsk",
 			},
 			opt: &options{
-				isDescOrder:  true,
-				sortColIndex: 3,
-				separator:    defaultSeparator,
+				keys:        []sortKey{newFieldKey(3, sortTypeDefautl, true)},
+				isDescOrder: true,
 			},
 		},
 		{
@@ -189,9 +213,7 @@ func TestColumnSortString(t *testing.T) {
 				"cspds gpfdp psdfd",
 			},
 			opt: &options{
-				isDescOrder:  false,
-				sortColIndex: 2,
-				separator:    defaultSeparator,
+				keys: []sortKey{newFieldKey(2, sortTypeDefautl, false)},
 			},
 		},
 		{
@@ -206,9 +228,8 @@ func TestColumnSortString(t *testing.T) {
 				"bbsdfpd ukdsk",
 			},
 			opt: &options{
-				isDescOrder:  true,
-				sortColIndex: 2,
-				separator:    defaultSeparator,
+				keys:        []sortKey{newFieldKey(2, sortTypeDefautl, true)},
+				isDescOrder: true,
 			},
 		},
 		{
@@ -223,9 +244,7 @@ func TestColumnSortString(t *testing.T) {
 				"fsdsda zpsd naso bcdpo",
 			},
 			opt: &options{
-				isDescOrder:  false,
-				sortColIndex: 1,
-				separator:    defaultSeparator,
+				keys: []sortKey{newFieldKey(1, sortTypeDefautl, false)},
 			},
 		},
 		{
@@ -240,16 +259,15 @@ func TestColumnSortString(t *testing.T) {
 				"zals",
 			},
 			opt: &options{
-				isDescOrder:  true,
-				sortColIndex: 1,
-				separator:    defaultSeparator,
+				keys:        []sortKey{newFieldKey(1, sortTypeDefautl, true)},
+				isDescOrder: true,
 			},
 		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			d := append([]string{}, tc.data...)
-			res := doColumnSort(tc.opt, d)
+			res := sortData(tc.opt, d)
 			assert.Equal(t, tc.expected, res)
 		})
 	}
@@ -275,10 +293,7 @@ func TestColumnSortNumeric(t *testing.T) {
 				"k 15 11",
 			},
 			opt: &options{
-				isDescOrder:  false,
-				sortColIndex: 2,
-				sortType:     sortTypeNumeric,
-				separator:    defaultSeparator,
+				keys: []sortKey{newFieldKey(2, sortTypeNumeric, false)},
 			},
 		},
 		{
@@ -292,10 +307,8 @@ func TestColumnSortNumeric(t *testing.T) {
 				"1 13",
 			},
 			opt: &options{
-				isDescOrder:  true,
-				sortColIndex: 2,
-				sortType:     sortTypeNumeric,
-				separator:    defaultSeparator,
+				keys:        []sortKey{newFieldKey(2, sortTypeNumeric, true)},
+				isDescOrder: true,
 			},
 		},
 		{
@@ -309,10 +322,7 @@ func TestColumnSortNumeric(t *testing.T) {
 				"4 85",
 			},
 			opt: &options{
-				isDescOrder:  false,
-				sortColIndex: 1,
-				sortType:     sortTypeNumeric,
-				separator:    defaultSeparator,
+				keys: []sortKey{newFieldKey(1, sortTypeNumeric, false)},
 			},
 		},
 		{
@@ -326,10 +336,8 @@ func TestColumnSortNumeric(t *testing.T) {
 				"9",
 			},
 			opt: &options{
-				isDescOrder:  true,
-				sortColIndex: 1,
-				sortType:     sortTypeNumeric,
-				separator:    defaultSeparator,
+				keys:        []sortKey{newFieldKey(1, sortTypeNumeric, true)},
+				isDescOrder: true,
 			},
 		},
 		{
@@ -343,10 +351,7 @@ func TestColumnSortNumeric(t *testing.T) {
 				"9",
 			},
 			opt: &options{
-				isDescOrder:  false,
-				sortColIndex: 0,
-				sortType:     sortTypeNumeric,
-				separator:    defaultSeparator,
+				keys: []sortKey{newFieldKey(0, sortTypeNumeric, false)},
 			},
 		},
 		{
@@ -360,10 +365,8 @@ func TestColumnSortNumeric(t *testing.T) {
 				"k 15 11",
 			},
 			opt: &options{
-				isDescOrder:  true,
-				sortColIndex: 0,
-				sortType:     sortTypeNumeric,
-				separator:    defaultSeparator,
+				keys:        []sortKey{newFieldKey(0, sortTypeNumeric, true)},
+				isDescOrder: true,
 			},
 		},
 		{
@@ -379,45 +382,98 @@ func TestColumnSortNumeric(t *testing.T) {
 				"1 001 5",
 			},
 			opt: &options{
-				isDescOrder:  false,
-				sortColIndex: 2,
-				sortType:     sortTypeNumeric,
-				separator:    defaultSeparator,
+				keys: []sortKey{newFieldKey(2, sortTypeNumeric, false)},
 			},
 		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			d := append([]string{}, tc.data...)
-			res := doColumnSort(tc.opt, d)
+			res := sortData(tc.opt, d)
 			assert.Equal(t, tc.expected, res)
 		})
 	}
 }
 
-func TestRemoveDuplicates(t *testing.T) {
-	data := []string{
-		"a",
-		"a",
-		"b",
+func TestStableAndLastResortSort(t *testing.T) {
+	baseInputData := []string{
+		"b 1",
+		"c 2",
+		"a 1",
+		"d 2",
+		"c 1",
 	}
-	expected := []string{
-		"a",
-		"b",
+
+	testCases := []struct {
+		name     string
+		opt      *options
+		expected []string
+	}{
+		{
+			name: "last resort asc",
+			opt: &options{
+				keys: []sortKey{newFieldKey(1, sortTypeNumeric, false)},
+			},
+			expected: []string{"a 1", "b 1", "c 1", "c 2", "d 2"},
+		},
+		{
+			name: "last resort is reversed by global reverse",
+			opt: &options{
+				keys:        []sortKey{newFieldKey(1, sortTypeNumeric, true)},
+				isDescOrder: true,
+			},
+			expected: []string{"d 2", "c 2", "c 1", "b 1", "a 1"},
+		},
+		{
+			name: "last resort is not reversed by key reverse",
+			opt: &options{
+				keys: []sortKey{newFieldKey(1, sortTypeNumeric, true)},
+			},
+			expected: []string{"c 2", "d 2", "a 1", "b 1", "c 1"},
+		},
+		{
+			name: "stable keeps input order of equal keys",
+			opt: &options{
+				keys:     []sortKey{newFieldKey(1, sortTypeNumeric, false)},
+				isStable: true,
+			},
+			expected: []string{"b 1", "a 1", "c 1", "c 2", "d 2"},
+		},
+		{
+			name: "stable reverse keeps input order of equal keys",
+			opt: &options{
+				keys:        []sortKey{newFieldKey(1, sortTypeNumeric, true)},
+				isDescOrder: true,
+				isStable:    true,
+			},
+			expected: []string{"c 2", "d 2", "b 1", "a 1", "c 1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				d := append([]string{}, baseInputData...)
+				assert.Equal(t, tc.expected, sortData(tc.opt, d))
+			}
+		})
 	}
-	t.Run("remove duplicates", func(t *testing.T) {
-		res := removeDuplicates(data)
-		assert.Equal(t, expected, res)
-	})
 }
 
-func TestSplitStringAndCleanUp(t *testing.T) {
-	sep := " "
-	src := " 1 03             4 "
-	expected := []string{" 1", "03", "4 "}
+func TestStableSortLargeInput(t *testing.T) {
+	data := make([]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		data = append(data, fmt.Sprintf("%d %04d", i%3, i))
+	}
+	opt := &options{
+		keys:     []sortKey{newFieldKey(0, sortTypeNumeric, true)},
+		isStable: true,
+	}
 
-	t.Run("separation and cleanup", func(t *testing.T) {
-		res := splitStringAndCleanUp(src, sep)
-		assert.Equal(t, expected, res)
-	})
+	res := sortData(opt, append([]string{}, data...))
+	for i := 1; i < len(res); i++ {
+		if res[i-1][0] == res[i][0] {
+			assert.Less(t, res[i-1], res[i])
+		}
+	}
 }
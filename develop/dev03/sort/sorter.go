@@ -0,0 +1,260 @@
+package sort
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// Options configure Sorter. Zero value sorts lines byte by byte in
+// ascending order like sort without flags.
+type Options struct {
+	// Keys are sort keys in POS1[,POS2][OPTS] format of -k flag,
+	// the whole line is the key if there are no keys
+	Keys []string
+
+	// sort types, at most one can be set
+	Numeric        bool // -n
+	GeneralNumeric bool // -g
+	HumanNumeric   bool // -h
+	Month          bool // -M
+	Random         bool // -R
+	Version        bool // -V
+
+	Reverse           bool // -r
+	FoldCase          bool // -f
+	Dictionary        bool // -d
+	IgnoreNonPrinting bool // -i
+	Unique            bool // -u
+	Count             bool // --count, implies Unique
+	Stable            bool // -s
+
+	// Locale of string collation, strings are compared byte by byte if empty
+	Locale string
+	// Separator of fields, fields are separated by blanks if both
+	// Separator and SeparatorRegexp are empty
+	Separator       string
+	SeparatorRegexp string
+
+	// RandomSource defines order of Random sort, system random source is
+	// used if nil
+	RandomSource io.Reader
+	// Parallel is the number of goroutines used for sorting in memory
+	Parallel int
+	// MemoryBudget is the size of data in bytes that is sorted in memory,
+	// the rest is spilled to temp files in TempDir. Zero means that all data
+	// is sorted in memory.
+	MemoryBudget int64
+	TempDir      string
+}
+
+// Disorder describes the first line that is out of order
+type Disorder struct {
+	Line int
+	Text string
+}
+
+// Sorter sorts lines like sort utility. Sorter is safe for concurrent use.
+type Sorter struct {
+	opt *options
+}
+
+// NewSorter validates options and returns Sorter
+func NewSorter(o Options) (*Sorter, error) {
+	opt, err := o.compile()
+	if err != nil {
+		return nil, err
+	}
+	return &Sorter{opt: opt}, nil
+}
+
+// Sort reads lines of inputs one after another, sorts them and writes
+// to w, every written line ends with new line
+func (s *Sorter) Sort(ctx context.Context, w io.Writer, inputs ...io.Reader) error {
+	dw := newStreamDataWriter(w)
+	defer dw.abort()
+
+	if err := s.sort(ctx, readerInputs(inputs), dw); err != nil {
+		return err
+	}
+	return dw.close()
+}
+
+// Merge merges lines of sorted inputs and writes them to w without
+// loading inputs into memory
+func (s *Sorter) Merge(ctx context.Context, w io.Writer, inputs ...io.Reader) error {
+	dw := newStreamDataWriter(w)
+	defer dw.abort()
+
+	if err := s.merge(ctx, readerInputs(inputs), dw); err != nil {
+		return err
+	}
+	return dw.close()
+}
+
+// Check returns the first line of r that is out of order or nil if r
+// is sorted. With Unique option lines with equal keys are out of order too.
+func (s *Sorter) Check(ctx context.Context, r io.Reader) (*Disorder, error) {
+	return checkSorted(ctx, s.opt, r)
+}
+
+func readerInputs(readers []io.Reader) []input {
+	out := make([]input, len(readers))
+	for i, r := range readers {
+		out[i] = input{reader: io.NopCloser(r)}
+	}
+	return out
+}
+
+func (o *Options) compile() (*options, error) {
+	if err := o.validateIncompatibleOptions(); err != nil {
+		return nil, err
+	}
+
+	if o.Parallel < 0 {
+		return nil, errInvalidParallel
+	}
+	parallel := o.Parallel
+	if parallel == 0 {
+		parallel = 1
+	}
+
+	if o.MemoryBudget < 0 {
+		return nil, errInvalidMemorySize
+	}
+
+	if o.Locale != "" {
+		if err := validateLocale(o.Locale); err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := o.getSortKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	splitter, err := o.getSplitter()
+	if err != nil {
+		return nil, err
+	}
+
+	randomSalt, err := newRandomSalt(o.RandomSource)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := &options{
+		keys:          keys,
+		sortType:      o.getSortType(),
+		isDescOrder:   o.Reverse,
+		isUniqueOnly:  o.Unique || o.Count,
+		isCountUnique: o.Count,
+		isStable:      o.Stable,
+		locale:        o.Locale,
+		randomSalt:    randomSalt,
+		parallel:      parallel,
+		splitter:      splitter,
+		memoryBudget:  o.MemoryBudget,
+		tempDir:       o.TempDir,
+	}
+	return opt, nil
+}
+
+// returns keys in order of comparison, whole line is the key by default
+func (o *Options) getSortKeys() ([]sortKey, error) {
+	global := o.getGlobalKey()
+	if len(o.Keys) == 0 {
+		return []sortKey{global}, nil
+	}
+
+	keys := make([]sortKey, 0, len(o.Keys))
+	for _, v := range o.Keys {
+		key, err := parseSortKey(v, global)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// returns whole line key with global ordering options
+func (o *Options) getGlobalKey() sortKey {
+	key := newWholeLineKey(o.getSortType(), o.Reverse)
+	key.isFoldCase = o.FoldCase
+	key.isDictionaryOrder = o.Dictionary
+	key.isIgnoreNonPrinting = o.IgnoreNonPrinting
+	return key
+}
+
+// returns nil if fields are separated by blanks
+func (o *Options) getSplitter() (fieldSplitter, error) {
+	if o.SeparatorRegexp != "" {
+		re, err := regexp.Compile(o.SeparatorRegexp)
+		if err != nil {
+			return nil, fmt.Errorf("sort: invalid separator regexp: %w", err)
+		}
+		return regexpSplitter{re: re}, nil
+	}
+
+	if o.Separator == "" {
+		return nil, nil
+	}
+	sep, err := parseSeparator(o.Separator)
+	if err != nil {
+		return nil, err
+	}
+	return newRuneSplitter(sep), nil
+}
+
+func (o *Options) validateIncompatibleOptions() error {
+	incompatible := []flagOpt{
+		{o.Numeric, numericSortFlag},
+		{o.HumanNumeric, numericHumanSortFlag},
+		{o.Month, monthNameSortFlag},
+		{o.GeneralNumeric, numericGeneralSortFlag},
+		{o.Random, randomSortFlag},
+		{o.Version, versionSortFlag},
+	}
+
+	if o.Separator != "" && o.SeparatorRegexp != "" {
+		return incompatibleOptionsErr("t", "separator-regexp")
+	}
+
+	name1 := ""
+	for _, f := range incompatible {
+		if f.value {
+			if name1 == "" {
+				name1 = f.name
+				continue
+			}
+			return incompatibleOptionsErr(name1, f.name)
+		}
+	}
+
+	return nil
+}
+
+func (o *Options) getSortType() int {
+	if o.Numeric {
+		return sortTypeNumeric
+	}
+	if o.HumanNumeric {
+		return sortTypeHumanNumeric
+	}
+	if o.Month {
+		return sortTypeMonth
+	}
+	if o.GeneralNumeric {
+		return sortTypeGeneralNumeric
+	}
+	if o.Random {
+		return sortTypeRandom
+	}
+	if o.Version {
+		return sortTypeVersion
+	}
+	return sortTypeDefautl
+}
@@ -0,0 +1,135 @@
+package sort
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSorterSort(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opt      Options
+		inputs   []string
+		expected string
+	}{
+		{
+			name:     "default",
+			inputs:   []string{"b\nc\n", "a"},
+			expected: "a\nb\nc\n",
+		},
+		{
+			name:     "no inputs",
+			expected: "",
+		},
+		{
+			name:     "numeric key reverse",
+			opt:      Options{Keys: []string{"2,2nr"}},
+			inputs:   []string{"a 2\nb 10\nc 1\n"},
+			expected: "b 10\na 2\nc 1\n",
+		},
+		{
+			name:     "count with separator",
+			opt:      Options{Keys: []string{"2,2"}, Separator: ":", Count: true},
+			inputs:   []string{"a:x\nb:y\nc:x\n"},
+			expected: "      2 a:x\n      1 b:y\n",
+		},
+		{
+			name:     "external",
+			opt:      Options{Version: true, MemoryBudget: 1, TempDir: t.TempDir()},
+			inputs:   []string{"v1.10\nv1.9\nv1.2\n"},
+			expected: "v1.2\nv1.9\nv1.10\n",
+		},
+		{
+			name:     "parallel",
+			opt:      Options{Month: true, Parallel: 4},
+			inputs:   []string{"mar\njan\nfeb\n"},
+			expected: "jan\nfeb\nmar\n",
+		},
+		{
+			name:     "random with source",
+			opt:      Options{Random: true, RandomSource: strings.NewReader(strings.Repeat("x", randomSaltSize))},
+			inputs:   []string{"a\nb\na\n"},
+			expected: "b\na\na\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewSorter(tc.opt)
+			assert.NoError(t, err)
+
+			var out bytes.Buffer
+			assert.NoError(t, s.Sort(context.Background(), &out, stringReaders(tc.inputs)...))
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestSorterMergeAndCheck(t *testing.T) {
+	s, err := NewSorter(Options{Numeric: true})
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	assert.NoError(t, s.Merge(ctx, &out, stringReaders([]string{"1\n10\n", "2\n3\n"})...))
+	assert.Equal(t, "1\n2\n3\n10\n", out.String())
+
+	d, err := s.Check(ctx, strings.NewReader(out.String()))
+	assert.NoError(t, err)
+	assert.Nil(t, d)
+
+	d, err = s.Check(ctx, strings.NewReader("1\n3\n2\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, &Disorder{Line: 3, Text: "2"}, d)
+}
+
+func TestSorterCanceled(t *testing.T) {
+	s, err := NewSorter(Options{})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	data := strings.Repeat("a\n", ctxCheckInterval*2)
+	var out bytes.Buffer
+	assert.ErrorIs(t, s.Sort(ctx, &out, strings.NewReader(data)), context.Canceled)
+	assert.ErrorIs(t, s.Merge(ctx, &out, strings.NewReader(data)), context.Canceled)
+	_, err = s.Check(ctx, strings.NewReader(data))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, out.String())
+}
+
+func TestNewSorterInvalidOptions(t *testing.T) {
+	testCases := []struct {
+		name string
+		opt  Options
+	}{
+		{name: "incompatible sort types", opt: Options{Numeric: true, Month: true}},
+		{name: "invalid key", opt: Options{Keys: []string{"0"}}},
+		{name: "invalid separator", opt: Options{Separator: "ab"}},
+		{name: "invalid separator regexp", opt: Options{SeparatorRegexp: "("}},
+		{name: "unknown locale", opt: Options{Locale: "xx-invalid-"}},
+		{name: "negative parallel", opt: Options{Parallel: -1}},
+		{name: "short random source", opt: Options{Random: true, RandomSource: strings.NewReader("x")}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewSorter(tc.opt)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func stringReaders(data []string) []io.Reader {
+	out := make([]io.Reader, len(data))
+	for i, v := range data {
+		out[i] = strings.NewReader(v)
+	}
+	return out
+}
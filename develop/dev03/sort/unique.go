@@ -0,0 +1,64 @@
+package sort
+
+import "fmt"
+
+// countFormat matches output of uniq -c
+const countFormat = "%7d %s"
+
+// uniqueLines keeps the first line of each run of lines with equal keys.
+// With count option the line is prefixed with the length of its run.
+func uniqueLines(cmp *lineComparator, lines []line, isCount bool) []string {
+	out := make([]string, 0)
+	for start := 0; start < len(lines); {
+		end := start + 1
+		for end < len(lines) && cmp.compare(lines[start], lines[end]) == 0 {
+			end++
+		}
+		out = append(out, uniqueText(lines[start].text, end-start, isCount))
+		start = end
+	}
+	return out
+}
+
+func uniqueText(s string, count int, isCount bool) string {
+	if isCount {
+		return fmt.Sprintf(countFormat, count, s)
+	}
+	return s
+}
+
+// uniqueWriter writes the first line of each run of sorted lines with equal
+// keys. Line is written when its run ends, so flush must be called after
+// the last line.
+type uniqueWriter struct {
+	cmp     *lineComparator
+	w       *dataWriter
+	isCount bool
+	first   line
+	count   int
+}
+
+func newUniqueWriter(cmp *lineComparator, w *dataWriter, isCount bool) *uniqueWriter {
+	return &uniqueWriter{cmp: cmp, w: w, isCount: isCount}
+}
+
+func (u *uniqueWriter) writeLine(l line) error {
+	if u.count > 0 && u.cmp.compare(u.first, l) == 0 {
+		u.count++
+		return nil
+	}
+	if err := u.flush(); err != nil {
+		return err
+	}
+	u.first, u.count = l, 1
+	return nil
+}
+
+func (u *uniqueWriter) flush() error {
+	if u.count == 0 {
+		return nil
+	}
+	count := u.count
+	u.count = 0
+	return u.w.writeLine(uniqueText(u.first.text, count, u.isCount))
+}
@@ -0,0 +1,89 @@
+package sort
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUniqueSort(t *testing.T) {
+	baseInputData := []string{
+		"b 2",
+		"a 1",
+		"c 2",
+		"a 1",
+		"d 1",
+		"B 3",
+	}
+
+	testCases := []struct {
+		name     string
+		opt      *options
+		expected []string
+	}{
+		{
+			name: "whole line",
+			opt: &options{
+				keys:         []sortKey{newWholeLineKey(sortTypeDefautl, false)},
+				isUniqueOnly: true,
+			},
+			expected: []string{"B 3", "a 1", "b 2", "c 2", "d 1"},
+		},
+		{
+			name: "first line of equal keys is kept",
+			opt: &options{
+				keys:         []sortKey{newFieldKey(1, sortTypeNumeric, false)},
+				isUniqueOnly: true,
+			},
+			expected: []string{"a 1", "b 2", "B 3"},
+		},
+		{
+			name: "reverse",
+			opt: &options{
+				keys:         []sortKey{newFieldKey(1, sortTypeNumeric, true)},
+				isDescOrder:  true,
+				isUniqueOnly: true,
+			},
+			expected: []string{"B 3", "b 2", "a 1"},
+		},
+		{
+			name: "fold case",
+			opt: &options{
+				keys:         []sortKey{{startField: 0, endField: 0, isFoldCase: true}},
+				isUniqueOnly: true,
+			},
+			expected: []string{"a 1", "b 2", "c 2", "d 1"},
+		},
+		{
+			name: "count whole line",
+			opt: &options{
+				keys:          []sortKey{newWholeLineKey(sortTypeDefautl, false)},
+				isUniqueOnly:  true,
+				isCountUnique: true,
+			},
+			expected: []string{"      1 B 3", "      2 a 1", "      1 b 2", "      1 c 2", "      1 d 1"},
+		},
+		{
+			name: "count by key",
+			opt: &options{
+				keys:          []sortKey{newFieldKey(1, sortTypeNumeric, false)},
+				isUniqueOnly:  true,
+				isCountUnique: true,
+			},
+			expected: []string{"      3 a 1", "      2 b 2", "      1 B 3"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := append([]string{}, baseInputData...)
+			res := sortData(tc.opt, d)
+			assert.Equal(t, tc.expected, res)
+
+			tc.opt.parallel = 2
+			d = append([]string{}, baseInputData...)
+			res = sortData(tc.opt, d)
+			assert.Equal(t, tc.expected, res, "parallel")
+		})
+	}
+}
@@ -4,155 +4,181 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
-	"unicode"
 )
 
-func splitStringAndCleanUp(s, sep string) []string {
-	sp := strings.Split(s, sep)
-	n := len(sp)
-	if n == 1 {
-		return sp
-	}
-	last := n - 1
-	firtsIsSep := false
-	out := []string{}
-	for i, v := range sp {
-		if i == 0 {
-			if v == "" {
-				firtsIsSep = true
-				continue
-			}
-			out = append(out, v)
-			continue
-		}
-		if i == 1 {
-			if firtsIsSep {
-				out = append(out, joinSep(v, sep, true))
-				continue
-			}
-		}
-		if i == last {
-			if v == "" {
-				outLast := len(out) - 1
-				out[outLast] = joinSep(out[outLast], sep, false)
-				continue
-			}
-			out = append(out, v)
-			continue
-		}
+func isASCIIDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
 
-		if v != "" {
-			out = append(out, v)
-		}
+func readData(scanner lineScanner) ([]string, error) {
+	out := make([]string, 0)
+
+	for scanner.Scan() {
+		out = append(out, scanner.Text())
 	}
-	return out
-}
 
-func joinSep(s, sep string, sepInfront bool) string {
-	if sepInfront {
-		return sep + s
+	if err := scanner.Err(); err != nil {
+		return nil, err
 	}
-	return s + sep
+
+	return out, nil
 }
 
-func startsWithDigit(s string) bool {
-	if s == "" {
-		return false
+func writeLines(data []string, w *dataWriter) error {
+	for _, v := range data {
+		if err := w.writeLine(v); err != nil {
+			return err
+		}
 	}
-	return unicode.IsDigit(rune(s[0]))
+	return nil
 }
 
-func getNumberFromStringStart(s string) (int, error) {
-	badret := func() (int, error) { return 0, errStrNotStartsWithNumber }
-	if s == "" {
-		return badret()
-	}
-	var b strings.Builder
+// dataWriter writes lines to stream or to file. Lines in file are separated
+// by new line while every line written to stream ends with new line.
+type dataWriter struct {
+	w        *bufio.Writer
+	file     *os.File
+	filePath string
+	tempPath string
+	count    int
+	closed   bool
+}
 
-	hasStartZero, hasNonZero := false, false
+func newStreamDataWriter(w io.Writer) *dataWriter {
+	return &dataWriter{w: bufio.NewWriter(w)}
+}
 
-	for _, r := range []rune(s) {
-		if !unicode.IsDigit(r) {
-			break
-		}
-		if r == '0' && !hasNonZero {
-			hasStartZero = true
-			continue
-		}
-		hasNonZero = true
-		b.WriteRune(r)
+// newDataWriter returns writer to file or to stdout if path is empty
+func newDataWriter(filePath string) (*dataWriter, error) {
+	if filePath == "" {
+		return newStreamDataWriter(os.Stdout), nil
 	}
-	n, err := strconv.Atoi(b.String())
+
+	file, err := os.Create(filePath)
 	if err != nil {
-		if hasStartZero {
-			return 0, nil
-		}
-		return badret()
+		fmt.Fprintf(os.Stderr, "unable to create file %s: %v\n", filePath, err)
+		return nil, errBadCreateFile
 	}
-	return n, nil
-}
 
-func readData(readCloser io.ReadCloser) ([]string, error) {
-	defer readCloser.Close()
-	scanner := bufio.NewScanner(readCloser)
-
-	out := make([]string, 0)
+	w := &dataWriter{
+		w:        bufio.NewWriter(file),
+		file:     file,
+		filePath: filePath,
+	}
+	return w, nil
+}
 
-	for scanner.Scan() {
-		out = append(out, scanner.Text())
+// newReplacingDataWriter returns writer that writes to temp file and
+// replaces the file with it on close, so the file can be read meanwhile
+func newReplacingDataWriter(filePath string) (*dataWriter, error) {
+	file, err := os.CreateTemp(filepath.Dir(filePath), tempOutPattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create temp file for %s: %v\n", filePath, err)
+		return nil, errBadCreateFile
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	w := &dataWriter{
+		w:        bufio.NewWriter(file),
+		file:     file,
+		filePath: filePath,
+		tempPath: file.Name(),
 	}
+	return w, nil
+}
 
-	return out, nil
+func (d *dataWriter) writeLine(s string) error {
+	var err error
+	if d.file == nil {
+		_, err = fmt.Fprintf(d.w, "%s\n", s)
+	} else {
+		if d.count > 0 {
+			s = fmt.Sprintf("\n%s", s)
+		}
+		_, err = d.w.WriteString(s)
+	}
+	if err != nil {
+		return d.writeErr(err)
+	}
+	d.count++
+	return nil
 }
 
-func writeData(data []string, filePath string) error {
-	if filePath == "" {
-		writeToStdOut(data)
+// close flushes data and closes file
+func (d *dataWriter) close() error {
+	if d.closed {
 		return nil
 	}
+	d.closed = true
 
-	file, err := os.Create(filePath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "unable to create file %s: %v\n", filePath, err)
-		return errBadOpenFile
-	}
-	for i, v := range data {
-		s := v
-		if i > 0 {
-			s = fmt.Sprintf("\n%s", v)
-		}
-		_, err := file.WriteString(s)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "unable to write to file file %s: %v\n", filePath, err)
-			return errWriteFail
+	err := d.w.Flush()
+	if d.file != nil {
+		if closeErr := d.file.Close(); err == nil {
+			err = closeErr
 		}
 	}
+	if err == nil && d.tempPath != "" {
+		err = os.Rename(d.tempPath, d.filePath)
+	}
+	if err != nil {
+		d.removeTemp()
+		return d.writeErr(err)
+	}
 	return nil
 }
 
-func writeToStdOut(data []string) {
-	for _, v := range data {
-		fmt.Fprintf(os.Stdout, "%s\n", v)
+// abort closes file without replacing the target file, does nothing
+// if writer is already closed
+func (d *dataWriter) abort() {
+	if d.closed {
+		return
 	}
+	d.closed = true
+
+	if d.file != nil {
+		d.w.Flush()
+		d.file.Close()
+	}
+	d.removeTemp()
 }
 
-func removeDuplicates(data []string) []string {
-	var exists = make(map[string]struct{})
-	var out = make([]string, 0)
+func (d *dataWriter) removeTemp() {
+	if d.tempPath != "" {
+		os.Remove(d.tempPath)
+	}
+}
 
-	for _, v := range data {
-		if _, has := exists[v]; !has {
-			exists[v] = struct{}{}
-			out = append(out, v)
+func (d *dataWriter) writeErr(err error) error {
+	if d.file == nil {
+		return fmt.Errorf("%w: %v", errWriteFail, err)
+	}
+	fmt.Fprintf(os.Stderr, "unable to write to file file %s: %v\n", d.filePath, err)
+	return errWriteFail
+}
+
+// parseMemorySize parses size in GNU sort -S format: number with optional
+// b, K, M, G or T suffix. Number without suffix means kibibytes.
+func parseMemorySize(s string) (int64, error) {
+	if s == "" {
+		return 0, errInvalidMemorySize
+	}
+
+	multiplier := int64(1024)
+	if i := strings.IndexByte(memorySizeSuffixes, s[len(s)-1]); i >= 0 {
+		multiplier = 1
+		for ; i > 0; i-- {
+			multiplier *= 1024
 		}
+		s = s[:len(s)-1]
 	}
 
-	return out
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 || n > math.MaxInt64/multiplier {
+		return 0, errInvalidMemorySize
+	}
+	return n * multiplier, nil
 }
@@ -0,0 +1,83 @@
+package sort
+
+import "strings"
+
+const (
+	// preReleaseMark goes before the end of version, e.g. 1.0~rc1 < 1.0
+	preReleaseMark = '~'
+	// preReleaseSep followed by a letter starts pre-release suffix,
+	// e.g. 1.0-rc1 < 1.0
+	preReleaseSep = '-'
+)
+
+// compareVersions compares strings as versions: digit runs are compared
+// as numbers and other parts char by char with letters going before other
+// chars, so v1.2.9 < v1.2.10 and 1.0-rc1 < 1.0 < 1.0-1
+func compareVersions(a, b string) int {
+	for a != "" || b != "" {
+		var textA, textB string
+		textA, a = splitVersionPart(a, false)
+		textB, b = splitVersionPart(b, false)
+		if res := compareVersionText(textA, textB); res != 0 {
+			return res
+		}
+
+		var numA, numB string
+		numA, a = splitVersionPart(a, true)
+		numB, b = splitVersionPart(b, true)
+		if res := compareVersionNumbers(numA, numB); res != 0 {
+			return res
+		}
+	}
+	return 0
+}
+
+// splitVersionPart returns leading run of digits or non digits and the rest
+func splitVersionPart(s string, isDigits bool) (string, string) {
+	i := 0
+	for i < len(s) && isASCIIDigit(s[i]) == isDigits {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func compareVersionText(a, b string) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		if res := compareOrdered(versionCharOrder(a, i), versionCharOrder(b, i)); res != 0 {
+			return res
+		}
+	}
+	return 0
+}
+
+// versionCharOrder returns weight of char at position i, end of string
+// weights 0
+func versionCharOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+
+	c := s[i]
+	switch {
+	case c == preReleaseMark:
+		return -1
+	case c == preReleaseSep && i+1 < len(s) && isASCIILetter(s[i+1]):
+		return -1
+	case isASCIILetter(c):
+		return int(c)
+	}
+	return int(c) + 256
+}
+
+func compareVersionNumbers(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if res := compareOrdered(len(a), len(b)); res != 0 {
+		return res
+	}
+	return strings.Compare(a, b)
+}
+
+func isASCIILetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,85 @@
+package sort
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareVersions(t *testing.T) {
+	testCases := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{a: "v1.2.9", b: "v1.2.10", expected: -1},
+		{a: "1.10", b: "1.9", expected: 1},
+		{a: "1.02", b: "1.2", expected: 0},
+		{a: "1.0", b: "1.0.1", expected: -1},
+		{a: "1.0-rc1", b: "1.0", expected: -1},
+		{a: "1.0~rc1", b: "1.0", expected: -1},
+		{a: "1.0-alpha", b: "1.0-beta", expected: -1},
+		{a: "1.0-rc2", b: "1.0-rc10", expected: -1},
+		{a: "1.0-rc1", b: "1.0.1", expected: -1},
+		{a: "1.0-1", b: "1.0", expected: 1},
+		{a: "1.0a", b: "1.0.1", expected: -1},
+		{a: "file9.txt", b: "file10.txt", expected: -1},
+		{a: "", b: "1", expected: -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			assert.Equal(t, tc.expected, compareVersions(tc.a, tc.b))
+			assert.Equal(t, -tc.expected, compareVersions(tc.b, tc.a))
+		})
+	}
+}
+
+func TestVersionSort(t *testing.T) {
+	data := []string{
+		"pkg v1.2.10",
+		"pkg v1.2.9",
+		"pkg v1.10.0",
+		"pkg v1.2.10-rc1",
+		"pkg v1.2.10-beta",
+		"pkg v1.2.1",
+	}
+
+	testCases := []struct {
+		name     string
+		opt      *options
+		expected []string
+	}{
+		{
+			name: "column asc",
+			opt:  &options{keys: []sortKey{newFieldKey(1, sortTypeVersion, false)}},
+			expected: []string{
+				"pkg v1.2.1",
+				"pkg v1.2.9",
+				"pkg v1.2.10-beta",
+				"pkg v1.2.10-rc1",
+				"pkg v1.2.10",
+				"pkg v1.10.0",
+			},
+		},
+		{
+			name: "column desc",
+			opt:  &options{keys: []sortKey{newFieldKey(1, sortTypeVersion, true)}, isDescOrder: true},
+			expected: []string{
+				"pkg v1.10.0",
+				"pkg v1.2.10",
+				"pkg v1.2.10-rc1",
+				"pkg v1.2.10-beta",
+				"pkg v1.2.9",
+				"pkg v1.2.1",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := append([]string{}, data...)
+			assert.Equal(t, tc.expected, sortData(tc.opt, d))
+		})
+	}
+}
@@ -2,9 +2,14 @@ module go-grep
 
 go 1.18
 
+require (
+	github.com/klauspost/compress v1.16.7
+	github.com/stretchr/testify v1.8.0
+	github.com/ulikunitz/xz v0.5.15
+)
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.8.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,179 @@
+package grep
+
+// acMatcher finds any of many fixed strings in one pass over the line
+// using Aho-Corasick automaton. Patterns and lines are folded if case
+// is ignored.
+type acMatcher struct {
+	nodes        []acNode
+	patterns     map[string]struct{}
+	isIgnoreCase bool
+	// hasEmpty is true if one of patterns is empty, it matches every line
+	hasEmpty bool
+}
+
+// acNode is a state of automaton, it corresponds to a prefix of patterns
+type acNode struct {
+	next map[byte]int32
+	fail int32
+	// length of pattern that ends in this node, 0 if there is no such pattern
+	length int
+	// dict is the nearest node of fail chain that ends a pattern, -1 if none
+	dict int32
+	// isTerminal is true if this node or any node of its fail chain ends
+	// a pattern
+	isTerminal bool
+}
+
+func newACMatcher(patterns []string, isIgnoreCase bool) *acMatcher {
+	m := &acMatcher{
+		nodes:        []acNode{newACNode()},
+		patterns:     make(map[string]struct{}, len(patterns)),
+		isIgnoreCase: isIgnoreCase,
+	}
+
+	for _, p := range patterns {
+		if isIgnoreCase {
+			p = foldCase(p)
+		}
+		m.patterns[p] = struct{}{}
+		if p == "" {
+			m.hasEmpty = true
+			continue
+		}
+		m.add(p)
+	}
+	m.build()
+	return m
+}
+
+func newACNode() acNode {
+	return acNode{next: make(map[byte]int32), dict: -1}
+}
+
+// add adds pattern to the trie of automaton
+func (m *acMatcher) add(p string) {
+	cur := int32(0)
+	for i := 0; i < len(p); i++ {
+		next, ok := m.nodes[cur].next[p[i]]
+		if !ok {
+			next = int32(len(m.nodes))
+			m.nodes = append(m.nodes, newACNode())
+			m.nodes[cur].next[p[i]] = next
+		}
+		cur = next
+	}
+	m.nodes[cur].length = len(p)
+	m.nodes[cur].isTerminal = true
+}
+
+// build sets fail and dictionary links walking trie in breadth-first order
+func (m *acMatcher) build() {
+	queue := make([]int32, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		for b, child := range m.nodes[cur].next {
+			fail := m.step(m.nodes[cur].fail, b)
+			node := &m.nodes[child]
+			node.fail = fail
+			if m.nodes[fail].length > 0 {
+				node.dict = fail
+			} else {
+				node.dict = m.nodes[fail].dict
+			}
+			node.isTerminal = node.isTerminal || m.nodes[fail].isTerminal
+			queue = append(queue, child)
+		}
+	}
+}
+
+// step returns state after reading byte b in state cur
+func (m *acMatcher) step(cur int32, b byte) int32 {
+	for {
+		if next, ok := m.nodes[cur].next[b]; ok {
+			return next
+		}
+		if cur == 0 {
+			return 0
+		}
+		cur = m.nodes[cur].fail
+	}
+}
+
+func (m *acMatcher) isMatch(s string) bool {
+	if m.hasEmpty {
+		return true
+	}
+	if m.isIgnoreCase {
+		s = foldCase(s)
+	}
+
+	cur := int32(0)
+	for i := 0; i < len(s); i++ {
+		cur = m.step(cur, s[i])
+		if m.nodes[cur].isTerminal {
+			return true
+		}
+	}
+	return false
+}
+
+// findAll returns leftmost longest non-overlapping matches
+func (m *acMatcher) findAll(s string) [][]int {
+	if m.isIgnoreCase {
+		s = foldCase(s)
+	}
+
+	// longestEnd[i] is the end of the longest match that starts at i
+	var longestEnd []int
+	cur := int32(0)
+	for i := 0; i < len(s); i++ {
+		cur = m.step(cur, s[i])
+		if !m.nodes[cur].isTerminal {
+			continue
+		}
+		if longestEnd == nil {
+			longestEnd = make([]int, len(s))
+		}
+
+		out := m.nodes[cur].dict
+		if m.nodes[cur].length > 0 {
+			out = cur
+		}
+		for ; out >= 0; out = m.nodes[out].dict {
+			start := i + 1 - m.nodes[out].length
+			longestEnd[start] = maxInt(longestEnd[start], i+1)
+		}
+	}
+
+	out := make([][]int, 0)
+	for i := 0; i < len(longestEnd); {
+		if longestEnd[i] == 0 {
+			i++
+			continue
+		}
+		out = append(out, []int{i, longestEnd[i]})
+		i = longestEnd[i]
+	}
+	return out
+}
+
+func (m *acMatcher) firstFrom(s string, pos int) []int {
+	if all := m.findAll(s[pos:]); len(all) > 0 {
+		return []int{pos + all[0][0], pos + all[0][1]}
+	}
+	return nil
+}
+
+func (m *acMatcher) isWhole(s string) bool {
+	if m.isIgnoreCase {
+		s = foldCase(s)
+	}
+	_, has := m.patterns[s]
+	return has
+}
@@ -0,0 +1,1053 @@
+package grep
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// backtrackBudget is the number of steps that backtracking matcher can do
+// searching a line. Line is treated as not matching if budget is exceeded,
+// so patterns like (a+)+b can't hang on long lines.
+const backtrackBudget = 1 << 24
+
+// backtrackMaxDepth limits nesting of matching calls, so stack stays small
+// on long lines. Repetition of a single rune is matched in a loop, other
+// repetitions nest a call per iteration. Line is treated as not matching
+// if the limit is reached.
+const backtrackMaxDepth = 1 << 16
+
+// backtrackMatcher finds Perl-compatible regexps: besides RE2 syntax they
+// may have lookarounds, backreferences, atomic groups and possessive
+// quantifiers. Like Perl, matcher prefers the first alternative, not the
+// longest one. exceeded is set atomically once matcher gives up on a line.
+type backtrackMatcher struct {
+	progs    []*btProgram
+	exceeded int32
+}
+
+// btProgram is a parsed pattern
+type btProgram struct {
+	root       *btNode
+	numGroups  int
+	isAnchored bool
+}
+
+type btKind int
+
+const (
+	btLiteral btKind = iota
+	btAnyChar
+	btClass
+	btBeginText
+	btEndText
+	btWordBoundary
+	btNoWordBoundary
+	btConcat
+	btAlternate
+	btRepeat
+	btCapture
+	btBackref
+	btLookaround
+	btAtomic
+)
+
+// btNode is a node of pattern syntax tree
+type btNode struct {
+	kind   btKind
+	r      rune
+	class  *btCharClass
+	isFold bool
+	subs   []*btNode
+	// min and max number of repetitions, max is negative if unbounded
+	min, max int
+	isLazy   bool
+	// index of capture group
+	index int
+	// name of group referenced by backreference
+	name      string
+	isNegated bool
+	isBehind  bool
+}
+
+func newBacktrackMatcher(patterns []string, isIgnoreCase bool) (*backtrackMatcher, error) {
+	m := &backtrackMatcher{progs: make([]*btProgram, len(patterns))}
+	for i, p := range patterns {
+		prog, err := parseBacktrack(p, isIgnoreCase)
+		if err != nil {
+			return nil, fmt.Errorf("grep: invalid pattern: %w", err)
+		}
+		m.progs[i] = prog
+	}
+	return m, nil
+}
+
+func (m *backtrackMatcher) isMatch(s string) bool {
+	return m.find(s, 0) != nil
+}
+
+func (m *backtrackMatcher) findAll(s string) [][]int {
+	out := make([][]int, 0)
+	for pos := 0; pos <= len(s); {
+		loc := m.next(s, pos)
+		if loc == nil {
+			break
+		}
+		out = append(out, loc)
+		pos = loc[1]
+	}
+	return out
+}
+
+func (m *backtrackMatcher) firstFrom(s string, pos int) []int {
+	return m.next(s, pos)
+}
+
+func (m *backtrackMatcher) isWhole(s string) bool {
+	for _, prog := range m.progs {
+		mc := newBTMachine(s, prog)
+		ok := mc.match(prog.root, 0, func(end int) bool { return end == len(s) }) && !mc.isExceeded()
+		m.checkBudget(mc)
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// next returns the leftmost non-empty match that starts at pos or later
+func (m *backtrackMatcher) next(s string, pos int) []int {
+	for pos <= len(s) {
+		loc := m.find(s, pos)
+		if loc == nil {
+			return nil
+		}
+		if loc[1] > loc[0] {
+			return loc
+		}
+		if loc[0] >= len(s) {
+			return nil
+		}
+		_, size := utf8.DecodeRuneInString(s[loc[0]:])
+		pos = loc[0] + size
+	}
+	return nil
+}
+
+// find returns the leftmost match of any pattern that starts at pos or
+// later, match of the first pattern wins if they start at the same byte
+func (m *backtrackMatcher) find(s string, pos int) []int {
+	var out []int
+	for _, prog := range m.progs {
+		end := len(s)
+		if out != nil {
+			// later pattern has to start before the found match
+			end = out[0] - 1
+		}
+		if loc := m.findProgram(prog, s, pos, end); loc != nil {
+			out = loc
+		}
+	}
+	return out
+}
+
+// findProgram returns the leftmost match that starts between pos and
+// end inclusive
+func (m *backtrackMatcher) findProgram(prog *btProgram, s string, pos, end int) []int {
+	if prog.isAnchored && pos > 0 {
+		return nil
+	}
+
+	mc := newBTMachine(s, prog)
+	defer m.checkBudget(mc)
+
+	matchEnd := 0
+	found := func(e int) bool {
+		matchEnd = e
+		return true
+	}
+	for start := pos; start <= end; {
+		// continuation may accept a shorter match after the limit is hit
+		if mc.match(prog.root, start, found) && !mc.isExceeded() {
+			return []int{start, matchEnd}
+		}
+		if prog.isAnchored || start >= len(s) || mc.isExceeded() {
+			break
+		}
+		_, size := utf8.DecodeRuneInString(s[start:])
+		start += size
+	}
+	return nil
+}
+
+// checkBudget remembers if matcher gave up on some line
+func (m *backtrackMatcher) checkBudget(mc *btMachine) {
+	if mc.isExceeded() {
+		atomic.StoreInt32(&m.exceeded, 1)
+	}
+}
+
+// isLimitExceeded reports whether matcher gave up on some line
+func (m *backtrackMatcher) isLimitExceeded() bool {
+	return atomic.LoadInt32(&m.exceeded) != 0
+}
+
+// btMachine keeps state of matching one line: bounds of captured groups
+// and the number of steps done
+type btMachine struct {
+	s     string
+	caps  []int
+	steps int
+	depth int
+}
+
+func newBTMachine(s string, prog *btProgram) *btMachine {
+	caps := make([]int, 2*(prog.numGroups+1))
+	for i := range caps {
+		caps[i] = -1
+	}
+	return &btMachine{s: s, caps: caps}
+}
+
+func (mc *btMachine) isExceeded() bool {
+	return mc.steps > backtrackBudget
+}
+
+// match matches node at byte i and calls k with the end of every possible
+// match in order of preference until k returns true
+func (mc *btMachine) match(n *btNode, i int, k func(int) bool) bool {
+	mc.steps++
+	if mc.isExceeded() {
+		return false
+	}
+	if mc.depth >= backtrackMaxDepth {
+		mc.steps = backtrackBudget + 1
+		return false
+	}
+
+	mc.depth++
+	res := mc.matchNode(n, i, k)
+	mc.depth--
+	return res
+}
+
+func (mc *btMachine) matchNode(n *btNode, i int, k func(int) bool) bool {
+	switch n.kind {
+	case btLiteral, btAnyChar, btClass:
+		if i >= len(mc.s) {
+			return false
+		}
+		r, size := utf8.DecodeRuneInString(mc.s[i:])
+		return n.matchesRune(r) && k(i+size)
+	case btBeginText:
+		return i == 0 && k(i)
+	case btEndText:
+		return i == len(mc.s) && k(i)
+	case btWordBoundary:
+		return isWordBoundary(mc.s, i) && k(i)
+	case btNoWordBoundary:
+		return !isWordBoundary(mc.s, i) && k(i)
+	case btConcat:
+		return mc.concat(n.subs, i, k)
+	case btAlternate:
+		for _, sub := range n.subs {
+			if mc.match(sub, i, k) {
+				return true
+			}
+		}
+		return false
+	case btRepeat:
+		if n.subs[0].isSingleRune() {
+			return mc.repeatRunes(n, i, k)
+		}
+		return mc.repeat(n, i, 0, k)
+	case btCapture:
+		return mc.capture(n, i, k)
+	case btBackref:
+		return mc.backref(n, i, k)
+	case btLookaround:
+		return mc.lookaround(n, i, k)
+	case btAtomic:
+		saved := mc.saveCaps()
+		end := -1
+		if !mc.match(n.subs[0], i, func(j int) bool { end = j; return true }) {
+			return false
+		}
+		if k(end) {
+			return true
+		}
+		copy(mc.caps, saved)
+		return false
+	}
+	panic("unknown node kind")
+}
+
+func (mc *btMachine) concat(subs []*btNode, i int, k func(int) bool) bool {
+	if len(subs) == 0 {
+		return k(i)
+	}
+	return mc.match(subs[0], i, func(j int) bool {
+		return mc.concat(subs[1:], j, k)
+	})
+}
+
+// repeat matches count-th and following repetitions of node, iteration
+// that matches empty string stops the loop
+func (mc *btMachine) repeat(n *btNode, i, count int, k func(int) bool) bool {
+	sub := n.subs[0]
+	if count < n.min {
+		return mc.match(sub, i, func(j int) bool {
+			return mc.repeat(n, j, count+1, k)
+		})
+	}
+
+	more := func() bool {
+		if n.max >= 0 && count >= n.max {
+			return false
+		}
+		return mc.match(sub, i, func(j int) bool {
+			return j != i && mc.repeat(n, j, count+1, k)
+		})
+	}
+	if n.isLazy {
+		return k(i) || more()
+	}
+	return more() || k(i)
+}
+
+// repeatRunes matches repetitions of node that matches a single rune,
+// possible ends are tried one after another without nesting
+func (mc *btMachine) repeatRunes(n *btNode, i int, k func(int) bool) bool {
+	sub := n.subs[0]
+	j, count := i, 0
+	next := func() bool {
+		mc.steps++
+		if mc.isExceeded() || (n.max >= 0 && count >= n.max) || j >= len(mc.s) {
+			return false
+		}
+		r, size := utf8.DecodeRuneInString(mc.s[j:])
+		if !sub.matchesRune(r) {
+			return false
+		}
+		j += size
+		count++
+		return true
+	}
+
+	if n.isLazy {
+		for {
+			if count >= n.min && k(j) {
+				return true
+			}
+			if !next() {
+				return false
+			}
+		}
+	}
+
+	for next() {
+	}
+	for count >= n.min && !mc.isExceeded() {
+		if k(j) {
+			return true
+		}
+		_, size := utf8.DecodeLastRuneInString(mc.s[i:j])
+		j -= size
+		count--
+	}
+	return false
+}
+
+func (mc *btMachine) capture(n *btNode, i int, k func(int) bool) bool {
+	idx := 2 * n.index
+	return mc.match(n.subs[0], i, func(j int) bool {
+		start, end := mc.caps[idx], mc.caps[idx+1]
+		mc.caps[idx], mc.caps[idx+1] = i, j
+		if k(j) {
+			return true
+		}
+		mc.caps[idx], mc.caps[idx+1] = start, end
+		return false
+	})
+}
+
+// backref matches text of the group, reference to unset group fails
+func (mc *btMachine) backref(n *btNode, i int, k func(int) bool) bool {
+	start, end := mc.caps[2*n.index], mc.caps[2*n.index+1]
+	if start < 0 {
+		return false
+	}
+
+	j := i
+	for _, r := range mc.s[start:end] {
+		if j >= len(mc.s) {
+			return false
+		}
+		r2, size := utf8.DecodeRuneInString(mc.s[j:])
+		if r2 != r && !(n.isFold && isEqualFold(r, r2)) {
+			return false
+		}
+		j += size
+	}
+	return k(j)
+}
+
+// lookaround checks that subpattern matches (or doesn't) after or before
+// byte i. Lookbehind may have variable length: every start before i is
+// tried. Like in Perl, lookaround is atomic.
+func (mc *btMachine) lookaround(n *btNode, i int, k func(int) bool) bool {
+	saved := mc.saveCaps()
+	sub := n.subs[0]
+
+	found := false
+	if n.isBehind {
+		isEnd := func(j int) bool { return j == i }
+		for start := i; start >= 0 && !found; start-- {
+			if start < len(mc.s) && !utf8.RuneStart(mc.s[start]) {
+				continue
+			}
+			found = mc.match(sub, start, isEnd)
+		}
+	} else {
+		found = mc.match(sub, i, func(int) bool { return true })
+	}
+
+	if found != n.isNegated && k(i) {
+		return true
+	}
+	copy(mc.caps, saved)
+	return false
+}
+
+func (mc *btMachine) saveCaps() []int {
+	return append([]int(nil), mc.caps...)
+}
+
+// isSingleRune reports whether node always matches exactly one rune
+func (n *btNode) isSingleRune() bool {
+	return n.kind == btLiteral || n.kind == btAnyChar || n.kind == btClass
+}
+
+func (n *btNode) matchesRune(r rune) bool {
+	switch n.kind {
+	case btLiteral:
+		return r == n.r || (n.isFold && isEqualFold(r, n.r))
+	case btAnyChar:
+		return r != '\n'
+	}
+	return n.class.matches(r, n.isFold)
+}
+
+// isWordBoundary reports whether word rune is on one side of byte i only
+func isWordBoundary(s string, i int) bool {
+	return isWordStart(s, i) != isWordEnd(s, i)
+}
+
+func isEqualFold(a, b rune) bool {
+	for f := unicode.SimpleFold(a); f != a; f = unicode.SimpleFold(f) {
+		if f == b {
+			return true
+		}
+	}
+	return false
+}
+
+// btCharClass is a set of runes: ranges are pairs of the first and the
+// last runes, funcs are escapes like \d
+type btCharClass struct {
+	ranges    []rune
+	funcs     []func(rune) bool
+	isNegated bool
+}
+
+func (c *btCharClass) matches(r rune, isFold bool) bool {
+	res := c.contains(r)
+	if !res && isFold {
+		for f := unicode.SimpleFold(r); f != r && !res; f = unicode.SimpleFold(f) {
+			res = c.contains(f)
+		}
+	}
+	return res != c.isNegated
+}
+
+func (c *btCharClass) contains(r rune) bool {
+	for i := 0; i < len(c.ranges); i += 2 {
+		if c.ranges[i] <= r && r <= c.ranges[i+1] {
+			return true
+		}
+	}
+	for _, f := range c.funcs {
+		if f(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigitRune(r rune) bool {
+	return unicode.IsDigit(r)
+}
+
+func isSpaceRune(r rune) bool {
+	return unicode.IsSpace(r)
+}
+
+func negate(f func(rune) bool) func(rune) bool {
+	return func(r rune) bool { return !f(r) }
+}
+
+// posixClasses are classes of [[:name:]] syntax
+var posixClasses = map[string]func(rune) bool{
+	"alpha":  unicode.IsLetter,
+	"digit":  isDigitRune,
+	"alnum":  func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) },
+	"space":  isSpaceRune,
+	"blank":  func(r rune) bool { return r == ' ' || r == '\t' },
+	"upper":  unicode.IsUpper,
+	"lower":  unicode.IsLower,
+	"punct":  unicode.IsPunct,
+	"print":  unicode.IsPrint,
+	"graph":  func(r rune) bool { return unicode.IsPrint(r) && r != ' ' },
+	"cntrl":  unicode.IsControl,
+	"xdigit": func(r rune) bool { return strings.ContainsRune("0123456789abcdefABCDEF", r) },
+	"word":   isWordRune,
+}
+
+// btParser parses pattern into syntax tree
+type btParser struct {
+	src       []rune
+	pos       int
+	isFold    bool
+	numGroups int
+	names     map[string]int
+	backrefs  []*btNode
+}
+
+func parseBacktrack(pattern string, isFold bool) (*btProgram, error) {
+	p := &btParser{src: []rune(pattern), isFold: isFold, names: make(map[string]int)}
+	root, err := p.parseAlternate()
+	if err != nil {
+		return nil, p.syntaxError(err.Error())
+	}
+	if p.pos < len(p.src) {
+		return nil, p.syntaxError("unexpected )")
+	}
+
+	for _, n := range p.backrefs {
+		if n.name != "" {
+			index, ok := p.names[n.name]
+			if !ok {
+				return nil, p.syntaxError("reference to non-existent group " + n.name)
+			}
+			n.index = index
+		}
+		if n.index > p.numGroups {
+			return nil, p.syntaxError("reference to non-existent group " + strconv.Itoa(n.index))
+		}
+	}
+
+	prog := &btProgram{root: root, numGroups: p.numGroups}
+	prog.isAnchored = root.kind == btBeginText ||
+		(root.kind == btConcat && len(root.subs) > 0 && root.subs[0].kind == btBeginText)
+	return prog, nil
+}
+
+func (p *btParser) syntaxError(msg string) error {
+	return fmt.Errorf("%s: `%s`", msg, string(p.src))
+}
+
+func (p *btParser) peek() (rune, bool) {
+	if p.pos >= len(p.src) {
+		return 0, false
+	}
+	return p.src[p.pos], true
+}
+
+// consume skips s if the rest of pattern starts with it
+func (p *btParser) consume(s string) bool {
+	rs := []rune(s)
+	if len(p.src)-p.pos < len(rs) {
+		return false
+	}
+	for i, r := range rs {
+		if p.src[p.pos+i] != r {
+			return false
+		}
+	}
+	p.pos += len(rs)
+	return true
+}
+
+func (p *btParser) parseAlternate() (*btNode, error) {
+	// inline flags are scoped by the enclosing group
+	isFold := p.isFold
+	defer func() { p.isFold = isFold }()
+
+	subs := make([]*btNode, 0, 1)
+	for {
+		n, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, n)
+		if !p.consume("|") {
+			break
+		}
+	}
+	if len(subs) == 1 {
+		return subs[0], nil
+	}
+	return &btNode{kind: btAlternate, subs: subs}, nil
+}
+
+func (p *btParser) parseConcat() (*btNode, error) {
+	subs := make([]*btNode, 0)
+	for {
+		r, ok := p.peek()
+		if !ok || r == '|' || r == ')' {
+			break
+		}
+		n, err := p.parseRepeat()
+		if err != nil {
+			return nil, err
+		}
+		if n != nil {
+			subs = append(subs, n)
+		}
+	}
+	if len(subs) == 1 {
+		return subs[0], nil
+	}
+	return &btNode{kind: btConcat, subs: subs}, nil
+}
+
+// parseRepeat parses atom with optional quantifier, returns nil for
+// inline flags that match nothing
+func (p *btParser) parseRepeat() (*btNode, error) {
+	atom, err := p.parseAtom()
+	if err != nil || atom == nil {
+		return atom, err
+	}
+
+	min, max, ok, err := p.parseQuantifier()
+	if err != nil || !ok {
+		return atom, err
+	}
+	if isAnchor(atom) {
+		return nil, fmt.Errorf("quantifier doesn't follow a repeatable item")
+	}
+
+	n := &btNode{kind: btRepeat, subs: []*btNode{atom}, min: min, max: max}
+	switch {
+	case p.consume("?"):
+		n.isLazy = true
+	case p.consume("+"):
+		// possessive quantifier is atomic group of greedy one
+		n = &btNode{kind: btAtomic, subs: []*btNode{n}}
+	}
+
+	if _, _, ok, _ := p.parseQuantifier(); ok {
+		return nil, fmt.Errorf("nested quantifier")
+	}
+	return n, nil
+}
+
+func isAnchor(n *btNode) bool {
+	switch n.kind {
+	case btBeginText, btEndText, btWordBoundary, btNoWordBoundary:
+		return true
+	}
+	return false
+}
+
+// parseQuantifier returns bounds of quantifier, ok is false if there is no
+// quantifier. Brace that doesn't start valid bounds is a literal.
+func (p *btParser) parseQuantifier() (int, int, bool, error) {
+	r, ok := p.peek()
+	if !ok {
+		return 0, 0, false, nil
+	}
+	switch r {
+	case '*':
+		p.pos++
+		return 0, -1, true, nil
+	case '+':
+		p.pos++
+		return 1, -1, true, nil
+	case '?':
+		p.pos++
+		return 0, 1, true, nil
+	case '{':
+	default:
+		return 0, 0, false, nil
+	}
+
+	end := p.pos + 1
+	for end < len(p.src) && p.src[end] != '}' {
+		end++
+	}
+	if end >= len(p.src) {
+		return 0, 0, false, nil
+	}
+	minStr, maxStr, hasComma := strings.Cut(string(p.src[p.pos+1:end]), ",")
+	min, err := strconv.Atoi(minStr)
+	if err != nil || min < 0 {
+		return 0, 0, false, nil
+	}
+	max := min
+	if hasComma {
+		max = -1
+		if maxStr != "" {
+			if max, err = strconv.Atoi(maxStr); err != nil || max < 0 {
+				return 0, 0, false, nil
+			}
+		}
+	}
+	if max >= 0 && max < min {
+		return 0, 0, false, fmt.Errorf("numbers out of order in {} quantifier")
+	}
+	p.pos = end + 1
+	return min, max, true, nil
+}
+
+func (p *btParser) parseAtom() (*btNode, error) {
+	r := p.src[p.pos]
+	p.pos++
+	switch r {
+	case '(':
+		return p.parseGroup()
+	case '[':
+		class, err := p.parseClass()
+		if err != nil {
+			return nil, err
+		}
+		return &btNode{kind: btClass, class: class, isFold: p.isFold}, nil
+	case '.':
+		return &btNode{kind: btAnyChar}, nil
+	case '^':
+		return &btNode{kind: btBeginText}, nil
+	case '$':
+		return &btNode{kind: btEndText}, nil
+	case '\\':
+		return p.parseEscape()
+	case '*', '+', '?':
+		return nil, fmt.Errorf("quantifier doesn't follow a repeatable item")
+	}
+	return p.literal(r), nil
+}
+
+func (p *btParser) literal(r rune) *btNode {
+	return &btNode{kind: btLiteral, r: r, isFold: p.isFold}
+}
+
+// parseGroup parses group after opening parenthesis
+func (p *btParser) parseGroup() (*btNode, error) {
+	n := &btNode{kind: btCapture}
+	switch {
+	case p.consume("?:"):
+		n = nil
+	case p.consume("?="):
+		n = &btNode{kind: btLookaround}
+	case p.consume("?!"):
+		n = &btNode{kind: btLookaround, isNegated: true}
+	case p.consume("?<="):
+		n = &btNode{kind: btLookaround, isBehind: true}
+	case p.consume("?<!"):
+		n = &btNode{kind: btLookaround, isBehind: true, isNegated: true}
+	case p.consume("?>"):
+		n = &btNode{kind: btAtomic}
+	case p.consume("?<") || p.consume("?P<"):
+		name, err := p.parseName('>')
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.names[name]; ok {
+			return nil, fmt.Errorf("duplicate group name %s", name)
+		}
+		p.numGroups++
+		p.names[name] = p.numGroups
+		n.index = p.numGroups
+	case p.consume("?"):
+		return p.parseFlags()
+	default:
+		p.numGroups++
+		n.index = p.numGroups
+	}
+
+	sub, err := p.parseAlternate()
+	if err != nil {
+		return nil, err
+	}
+	if !p.consume(")") {
+		return nil, fmt.Errorf("missing )")
+	}
+	if n == nil {
+		return sub, nil
+	}
+	n.subs = []*btNode{sub}
+	return n, nil
+}
+
+// parseFlags parses (?flags) that apply to the rest of enclosing group
+// and (?flags:re) that apply to re only
+func (p *btParser) parseFlags() (*btNode, error) {
+	isFold, isNegated := p.isFold, false
+	for {
+		r, ok := p.peek()
+		if !ok {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		switch r {
+		case 'i':
+			isFold = !isNegated
+		case '-':
+			isNegated = true
+		case ')':
+			p.isFold = isFold
+			return nil, nil
+		case ':':
+			saved := p.isFold
+			p.isFold = isFold
+			sub, err := p.parseAlternate()
+			p.isFold = saved
+			if err != nil {
+				return nil, err
+			}
+			if !p.consume(")") {
+				return nil, fmt.Errorf("missing )")
+			}
+			return sub, nil
+		default:
+			return nil, fmt.Errorf("unsupported group flag %c", r)
+		}
+	}
+}
+
+func (p *btParser) parseName(end rune) (string, error) {
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != end {
+		if !isWordRune(p.src[p.pos]) {
+			return "", fmt.Errorf("invalid group name")
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.src) || p.pos == start {
+		return "", fmt.Errorf("invalid group name")
+	}
+	p.pos++
+	return string(p.src[start : p.pos-1]), nil
+}
+
+func (p *btParser) parseEscape() (*btNode, error) {
+	r, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	p.pos++
+
+	switch r {
+	case 'b':
+		return &btNode{kind: btWordBoundary}, nil
+	case 'B':
+		return &btNode{kind: btNoWordBoundary}, nil
+	case 'A':
+		return &btNode{kind: btBeginText}, nil
+	case 'z', 'Z':
+		return &btNode{kind: btEndText}, nil
+	case 'k':
+		var name string
+		var err error
+		switch {
+		case p.consume("<"):
+			name, err = p.parseName('>')
+		case p.consume("{"):
+			name, err = p.parseName('}')
+		default:
+			err = fmt.Errorf("invalid \\k reference")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return p.backref(&btNode{kind: btBackref, name: name}), nil
+	}
+
+	if r >= '1' && r <= '9' {
+		start := p.pos - 1
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		index, _ := strconv.Atoi(string(p.src[start:p.pos]))
+		return p.backref(&btNode{kind: btBackref, index: index}), nil
+	}
+
+	if f := escapeClass(r); f != nil {
+		return &btNode{kind: btClass, class: &btCharClass{funcs: []func(rune) bool{f}}}, nil
+	}
+
+	lit, err := p.parseEscapedRune(r)
+	if err != nil {
+		return nil, err
+	}
+	return p.literal(lit), nil
+}
+
+func (p *btParser) backref(n *btNode) *btNode {
+	n.isFold = p.isFold
+	p.backrefs = append(p.backrefs, n)
+	return n
+}
+
+// escapeClass returns class of escapes like \d, nil if rune is not a class
+func escapeClass(r rune) func(rune) bool {
+	switch r {
+	case 'd':
+		return isDigitRune
+	case 'D':
+		return negate(isDigitRune)
+	case 'w':
+		return isWordRune
+	case 'W':
+		return negate(isWordRune)
+	case 's':
+		return isSpaceRune
+	case 'S':
+		return negate(isSpaceRune)
+	}
+	return nil
+}
+
+// parseEscapedRune returns rune of escape sequence after backslash and r
+func (p *btParser) parseEscapedRune(r rune) (rune, error) {
+	switch r {
+	case 't':
+		return '\t', nil
+	case 'n':
+		return '\n', nil
+	case 'r':
+		return '\r', nil
+	case 'f':
+		return '\f', nil
+	case 'v':
+		return '\v', nil
+	case 'a':
+		return '\a', nil
+	case 'e':
+		return 0x1b, nil
+	case '0':
+		return 0, nil
+	case 'x':
+		return p.parseHex()
+	}
+	if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+		return 0, fmt.Errorf("unsupported escape \\%c", r)
+	}
+	return r, nil
+}
+
+// parseHex parses \xHH and \x{HHHH} escapes after x
+func (p *btParser) parseHex() (rune, error) {
+	var digits string
+	if p.consume("{") {
+		end := p.pos
+		for end < len(p.src) && p.src[end] != '}' {
+			end++
+		}
+		if end >= len(p.src) {
+			return 0, fmt.Errorf("missing } in \\x{}")
+		}
+		digits = string(p.src[p.pos:end])
+		p.pos = end + 1
+	} else {
+		end := p.pos
+		for end < len(p.src) && end < p.pos+2 && strings.ContainsRune("0123456789abcdefABCDEF", p.src[end]) {
+			end++
+		}
+		digits = string(p.src[p.pos:end])
+		p.pos = end
+	}
+
+	v, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || v > unicode.MaxRune {
+		return 0, fmt.Errorf("invalid hex escape")
+	}
+	return rune(v), nil
+}
+
+// parseClass parses bracket expression after opening bracket
+func (p *btParser) parseClass() (*btCharClass, error) {
+	c := &btCharClass{}
+	if p.consume("^") {
+		c.isNegated = true
+	}
+
+	for isFirst := true; ; isFirst = false {
+		r, ok := p.peek()
+		if !ok {
+			return nil, fmt.Errorf("missing terminating ] for character class")
+		}
+		if r == ']' && !isFirst {
+			p.pos++
+			return c, nil
+		}
+
+		if p.consume("[:") {
+			name, err := p.parseName(':')
+			f, ok := posixClasses[name]
+			if err != nil || !ok || !p.consume("]") {
+				return nil, fmt.Errorf("unknown POSIX class name")
+			}
+			c.funcs = append(c.funcs, f)
+			continue
+		}
+
+		lo, f, err := p.parseClassRune()
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			c.funcs = append(c.funcs, f)
+			continue
+		}
+
+		hi := lo
+		if p.pos+1 < len(p.src) && p.src[p.pos] == '-' && p.src[p.pos+1] != ']' {
+			p.pos++
+			var hf func(rune) bool
+			if hi, hf, err = p.parseClassRune(); err != nil {
+				return nil, err
+			}
+			if hf != nil || hi < lo {
+				return nil, fmt.Errorf("invalid range in character class")
+			}
+		}
+		c.ranges = append(c.ranges, lo, hi)
+	}
+}
+
+// parseClassRune returns rune of bracket expression or class of escape
+// like \d
+func (p *btParser) parseClassRune() (rune, func(rune) bool, error) {
+	r := p.src[p.pos]
+	p.pos++
+	if r != '\\' {
+		return r, nil, nil
+	}
+
+	r, ok := p.peek()
+	if !ok {
+		return 0, nil, fmt.Errorf("trailing backslash")
+	}
+	p.pos++
+	if f := escapeClass(r); f != nil {
+		return 0, f, nil
+	}
+	if r == 'b' {
+		return '\b', nil, nil
+	}
+	lit, err := p.parseEscapedRune(r)
+	return lit, nil, err
+}
@@ -0,0 +1,116 @@
+package grep
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBacktrackMatcher(t *testing.T) {
+	testCases := []struct {
+		name         string
+		patterns     []string
+		isIgnoreCase bool
+		data         string
+		expected     [][]int
+	}{
+		{name: "literal", patterns: []string{"ab"}, data: "xabab", expected: [][]int{{1, 3}, {3, 5}}},
+		{name: "first alternative wins", patterns: []string{"ab|abc"}, data: "abcd", expected: [][]int{{0, 2}}},
+		{name: "greedy", patterns: []string{"a.*b"}, data: "aXbYb", expected: [][]int{{0, 5}}},
+		{name: "lazy", patterns: []string{"a.*?b"}, data: "aXbYb", expected: [][]int{{0, 3}}},
+		{name: "counted repetition", patterns: []string{`\d{2,3}`}, data: "1 12 1234", expected: [][]int{{2, 4}, {5, 8}}},
+		{name: "brace is literal", patterns: []string{"a{x}"}, data: "a{x}", expected: [][]int{{0, 4}}},
+		{name: "class", patterns: []string{"[a-c-]+"}, data: "xa-cbz", expected: [][]int{{1, 5}}},
+		{name: "negated class", patterns: []string{`[^\d\s]+`}, data: "12 ab3", expected: [][]int{{3, 5}}},
+		{name: "posix class", patterns: []string{"[[:upper:]]+"}, data: "abCDe", expected: [][]int{{2, 4}}},
+		{name: "anchors", patterns: []string{"^a|b$"}, data: "abab", expected: [][]int{{0, 1}, {3, 4}}},
+		{name: "unicode word boundary", patterns: []string{`\bкот\b`}, data: "скот кот", expected: [][]int{{9, 15}}},
+		{name: "lookahead", patterns: []string{`foo(?=bar)`}, data: "foobaz foobar", expected: [][]int{{7, 10}}},
+		{name: "negative lookahead", patterns: []string{`foo(?!bar)`}, data: "foobar foobaz", expected: [][]int{{7, 10}}},
+		{name: "lookbehind", patterns: []string{`(?<=\$)\d+`}, data: "1 $25 3", expected: [][]int{{3, 5}}},
+		{name: "negative lookbehind", patterns: []string{`(?<!\$)\b\d+`}, data: "$25 30", expected: [][]int{{4, 6}}},
+		{name: "variable length lookbehind", patterns: []string{`(?<=ab+)c`}, data: "ac abbbc", expected: [][]int{{7, 8}}},
+		{name: "backreference", patterns: []string{`(\w)\1`}, data: "abccd", expected: [][]int{{2, 4}}},
+		{name: "backreference of repeated word", patterns: []string{`\b(\w+) \1\b`}, data: "it is is it", expected: [][]int{{3, 8}}},
+		{name: "named backreference", patterns: []string{`(?<q>['"]).*?\k<q>`}, data: `x "a'b" y`, expected: [][]int{{2, 7}}},
+		{name: "backreference ignore case", patterns: []string{`(ab)\1`}, isIgnoreCase: true, data: "xAbaB", expected: [][]int{{1, 5}}},
+		{name: "inline flag", patterns: []string{`a(?i)b`}, data: "aB AB", expected: [][]int{{0, 2}}},
+		{name: "scoped flag", patterns: []string{`(?i:a)b`}, data: "AB Ab", expected: [][]int{{3, 5}}},
+		{name: "atomic group", patterns: []string{`(?>a+)b|a+c`}, data: "aaac", expected: [][]int{{0, 4}}},
+		{name: "possessive", patterns: []string{`a++a`}, data: "aaaa", expected: [][]int{}},
+		{name: "empty matches are skipped", patterns: []string{"x*"}, data: "axxb", expected: [][]int{{1, 3}}},
+		{name: "escapes", patterns: []string{`\x41\t\.`}, data: "A\t.", expected: [][]int{{0, 3}}},
+		{name: "cyrillic ignore case", patterns: []string{"привет"}, isIgnoreCase: true, data: "ПРИВЕТ", expected: [][]int{{0, 12}}},
+		{name: "many patterns", patterns: []string{"b+", "a"}, data: "abba", expected: [][]int{{0, 1}, {1, 3}, {3, 4}}},
+		{name: "first pattern wins at the same start", patterns: []string{"a", "ab"}, data: "ab", expected: [][]int{{0, 1}}},
+		{name: "no match", patterns: []string{"z"}, data: "abc", expected: [][]int{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := newMatcher(&options{patterns: tc.patterns, isPerlRegexp: true, isIgnoreCase: tc.isIgnoreCase})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, m.findAll(tc.data))
+			assert.Equal(t, len(tc.expected) > 0, m.isMatch(tc.data))
+		})
+	}
+}
+
+func TestBacktrackMatcherWhole(t *testing.T) {
+	m, err := newMatcher(&options{patterns: []string{`(a|ab)(c|bcd)`}, isPerlRegexp: true, isLineMatch: true})
+	assert.NoError(t, err)
+	assert.True(t, m.isMatch("abcd"))
+	assert.False(t, m.isMatch("abcdx"))
+
+	m, err = newMatcher(&options{patterns: []string{`\d+`}, isPerlRegexp: true, isWordMatch: true})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int{{4, 6}}, m.findAll("a12 34 5b"))
+}
+
+func TestBacktrackBudget(t *testing.T) {
+	m, err := newMatcher(&options{patterns: []string{`(a+)+b`}, isPerlRegexp: true})
+	assert.NoError(t, err)
+	assert.False(t, isLimitExceeded(m))
+	assert.False(t, m.isMatch(strings.Repeat("a", 40)))
+	assert.True(t, isLimitExceeded(m))
+	assert.True(t, m.isMatch(strings.Repeat("a", 40)+"b"))
+}
+
+func TestBacktrackLongLine(t *testing.T) {
+	line := strings.Repeat("a", 4<<20)
+	testCases := []struct {
+		pattern  string
+		expected bool
+	}{
+		{pattern: `^a*$`, expected: true},
+		{pattern: `^a+?$`, expected: true},
+		{pattern: `^a{2,}b?$`, expected: true},
+		// limit is exceeded, but stack doesn't overflow
+		{pattern: `a*b`, expected: false},
+		{pattern: `^(?:a|b)*$`, expected: false},
+		{pattern: `^(a)*$`, expected: false},
+		// shorter match found after the limit is hit is not reported
+		{pattern: `^(a)+`, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			m, err := newMatcher(&options{patterns: []string{tc.pattern}, isPerlRegexp: true})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, m.isMatch(line))
+			assert.Equal(t, !tc.expected, isLimitExceeded(m))
+		})
+	}
+}
+
+func TestInvalidBacktrackPattern(t *testing.T) {
+	patterns := []string{
+		"a(", "a)", "[a", "*a", "a**", `(a)\2`, `\k<x>(?<y>a)`, `(?<x>a)(?<x>b)`,
+		"a{3,1}", `\`, `\q`, "[z-a]", "[[:nope:]]", "(?x)",
+	}
+	for _, p := range patterns {
+		_, err := newMatcher(&options{patterns: []string{p}, isPerlRegexp: true})
+		assert.Error(t, err, p)
+	}
+}
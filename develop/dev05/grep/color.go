@@ -0,0 +1,111 @@
+package grep
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+
+	grepColorsEnv = "GREP_COLORS"
+)
+
+// colors are SGR codes of parts of output, empty code means no color
+type colors struct {
+	selectedMatch string
+	contextMatch  string
+	filename      string
+	lineNum       string
+	byteOffset    string
+	separator     string
+}
+
+func defaultColors() colors {
+	return colors{
+		selectedMatch: "01;31",
+		contextMatch:  "01;31",
+		filename:      "35",
+		lineNum:       "32",
+		byteOffset:    "32",
+		separator:     "36",
+	}
+}
+
+// parseGrepColors overrides default colors by capabilities of GREP_COLORS
+// value, e.g. "ms=01;32:fn=34". Unknown capabilities are ignored.
+func parseGrepColors(s string) colors {
+	c := defaultColors()
+	for _, v := range strings.Split(s, ":") {
+		name, code, _ := strings.Cut(v, "=")
+		switch name {
+		case "mt":
+			c.selectedMatch, c.contextMatch = code, code
+		case "ms":
+			c.selectedMatch = code
+		case "mc":
+			c.contextMatch = code
+		case "fn":
+			c.filename = code
+		case "ln":
+			c.lineNum = code
+		case "bn":
+			c.byteOffset = code
+		case "se":
+			c.separator = code
+		}
+	}
+	return c
+}
+
+// paint wraps string into SGR sequences of the code
+func paint(code, s string) string {
+	if code == "" || s == "" {
+		return s
+	}
+	return "\x1b[" + code + "m\x1b[K" + s + "\x1b[m\x1b[K"
+}
+
+// colorFlag is --color flag, it can be set without value
+type colorFlag string
+
+func (c *colorFlag) String() string {
+	return string(*c)
+}
+
+func (c *colorFlag) Set(value string) error {
+	switch value {
+	case "true":
+		value = colorAuto
+	case colorAuto, colorAlways, colorNever:
+	default:
+		return errInvalidColor
+	}
+	*c = colorFlag(value)
+	return nil
+}
+
+func (c *colorFlag) IsBoolFlag() bool {
+	return true
+}
+
+// getColors returns colors without codes if output shouldn't be colored
+func getColors(mode string) colors {
+	switch mode {
+	case colorAlways:
+	case colorAuto:
+		if !isTerminal(os.Stdout) || os.Getenv("TERM") == "dumb" {
+			return colors{}
+		}
+	default:
+		return colors{}
+	}
+	return parseGrepColors(os.Getenv(grepColorsEnv))
+}
+
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
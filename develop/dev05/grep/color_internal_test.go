@@ -0,0 +1,52 @@
+package grep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGrepColors(t *testing.T) {
+	c := parseGrepColors("mt=01;32:fn=34:ln=:xx=1:ne")
+	expected := defaultColors()
+	expected.selectedMatch = "01;32"
+	expected.contextMatch = "01;32"
+	expected.filename = "34"
+	expected.lineNum = ""
+	assert.Equal(t, expected, c)
+
+	assert.Equal(t, defaultColors(), parseGrepColors(""))
+}
+
+func TestColorFlag(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected string
+		isError  bool
+	}{
+		{value: "true", expected: colorAuto},
+		{value: "always", expected: colorAlways},
+		{value: "never", expected: colorNever},
+		{value: "sometimes", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			var c colorFlag
+			err := c.Set(tc.value)
+			if tc.isError {
+				assert.ErrorIs(t, err, errInvalidColor)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, c.String())
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	res := highlight("abcab", [][]int{{0, 1}, {3, 4}}, "31")
+	assert.Equal(t, "\x1b[31m\x1b[Ka\x1b[m\x1b[Kbc\x1b[31m\x1b[Ka\x1b[m\x1b[Kb", res)
+	assert.Equal(t, "abc", highlight("abc", nil, "31"))
+	assert.Equal(t, "abc", paint("", "abc"))
+}
@@ -0,0 +1,71 @@
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// magicPeekSize is the length of the longest magic prefix
+const magicPeekSize = 6
+
+// compressFormat is a compressed data format detected by magic bytes
+// at the start of data
+type compressFormat struct {
+	magic []byte
+	open  func(r io.Reader) (io.ReadCloser, error)
+}
+
+var compressFormats = []compressFormat{
+	{
+		magic: []byte{0x1f, 0x8b},
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		magic: []byte("BZh"),
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		},
+	},
+	{
+		magic: []byte{0x28, 0xb5, 0x2f, 0xfd},
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		},
+	},
+	{
+		magic: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00},
+		open: func(r io.Reader) (io.ReadCloser, error) {
+			d, err := xz.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(d), nil
+		},
+	},
+}
+
+// openDecompressed returns reader of decompressed data if data is
+// compressed by gzip, bzip2, zstd or xz, otherwise data is read as is.
+// Closing of the returned reader doesn't close r.
+func openDecompressed(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(magicPeekSize)
+	for _, f := range compressFormats {
+		if bytes.HasPrefix(head, f.magic) {
+			return f.open(br)
+		}
+	}
+	return io.NopCloser(br), nil
+}
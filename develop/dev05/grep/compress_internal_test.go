@@ -0,0 +1,70 @@
+package grep
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/ulikunitz/xz"
+)
+
+func TestOpenDecompressed(t *testing.T) {
+	text := "alpha\nbeta\n"
+
+	compress := func(newWriter func(w io.Writer) (io.WriteCloser, error)) []byte {
+		var buf bytes.Buffer
+		w, err := newWriter(&buf)
+		assert.NoError(t, err)
+		_, err = io.WriteString(w, text)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	// output of bzip2 for text, standard library can only decompress it
+	bzip2Data, err := hex.DecodeString("425a6839314159265359b5664df100000241800010324444002000310c081a0c9ea5a26a640f177245385090b5664df1")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "plain", data: []byte(text)},
+		{name: "gzip", data: compress(func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil })},
+		{name: "bzip2", data: bzip2Data},
+		{name: "zstd", data: compress(func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) })},
+		{name: "xz", data: compress(func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) })},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := openDecompressed(bytes.NewReader(tc.data))
+			assert.NoError(t, err)
+			defer r.Close()
+
+			res, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, text, string(res))
+		})
+	}
+}
+
+func TestOpenDecompressedShortData(t *testing.T) {
+	for _, data := range []string{"", "a", "\x1f"} {
+		r, err := openDecompressed(bytes.NewReader([]byte(data)))
+		assert.NoError(t, err)
+
+		res, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, data, string(res))
+	}
+}
+
+func TestOpenDecompressedCorrupted(t *testing.T) {
+	_, err := openDecompressed(bytes.NewReader([]byte{0x1f, 0x8b, 0x00}))
+	assert.Error(t, err)
+}
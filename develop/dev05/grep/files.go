@@ -0,0 +1,137 @@
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// binaryPeekSize is the size of input buffer, at most this number of bytes
+// at the start of file is checked to detect binary file
+const binaryPeekSize = 8 << 10
+
+// input is an opened file to search
+type input struct {
+	name   string
+	reader io.ReadCloser
+}
+
+// walkPaths calls fn for every file to search: files of paths and, with
+// recursive option, regular files of directories. Paths that can't be
+// searched are passed to fn with error, they don't stop the walk. The walk
+// stops when fn returns false.
+func walkPaths(opt *options, fn func(path string, err error) bool) {
+	for _, path := range opt.paths {
+		if !walkPath(opt, path, fn) {
+			return
+		}
+	}
+}
+
+func walkPath(opt *options, path string, fn func(path string, err error) bool) bool {
+	if path == stdinPath {
+		return fn(path, nil)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fn(path, err)
+	}
+	if !info.IsDir() {
+		return !opt.isFileIncluded(path) || fn(path, nil)
+	}
+	if !opt.isRecursive {
+		return fn(path, errIsDirectory)
+	}
+
+	isContinue := true
+	filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			isContinue = fn(p, err)
+		} else if d.Type().IsRegular() && opt.isFileIncluded(p) {
+			isContinue = fn(p, nil)
+		}
+		if !isContinue {
+			return errStopWalk
+		}
+		return nil
+	})
+	return isContinue
+}
+
+// errorReporter prints errors of files to stderr unless it is silent,
+// err is errBadOpenFile if any error was reported
+type errorReporter struct {
+	isSilent bool
+	err      error
+}
+
+func (r *errorReporter) report(path string, err error) {
+	if !r.isSilent {
+		fmt.Fprintln(os.Stderr, fileErrorMessage(path, err))
+	}
+	r.err = errBadOpenFile
+}
+
+// fileErrorMessage returns message about error of file, path is printed
+// once even if error has it too
+func fileErrorMessage(path string, err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	return fmt.Sprintf("grep: %s: %v", inputName(path), err)
+}
+
+// inputName returns name of the file that is printed in output
+func inputName(path string) string {
+	if path == stdinPath {
+		return stdinName
+	}
+	return path
+}
+
+func openInput(path string) (input, error) {
+	if path == stdinPath {
+		return input{name: inputName(path), reader: os.Stdin}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return input{}, err
+	}
+	return input{name: path, reader: file}, nil
+}
+
+// isFileIncluded reports whether base name of the file matches include
+// globs, if any, and doesn't match exclude globs
+func (o *options) isFileIncluded(path string) bool {
+	name := filepath.Base(path)
+	if len(o.includeGlobs) > 0 && !matchesAnyGlob(name, o.includeGlobs) {
+		return false
+	}
+	return !matchesAnyGlob(name, o.excludeGlobs)
+}
+
+func matchesAnyGlob(name string, globs []string) bool {
+	for _, v := range globs {
+		if ok, _ := filepath.Match(v, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isBinaryData reports whether the start of data contains zero byte.
+// Only data of the first read is checked, so it doesn't block on input
+// that is written slowly.
+func isBinaryData(r *bufio.Reader) bool {
+	r.Peek(1)
+	head, _ := r.Peek(r.Buffered())
+	return bytes.IndexByte(head, 0) >= 0
+}
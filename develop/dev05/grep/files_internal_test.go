@@ -0,0 +1,341 @@
+package grep
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestFiles creates files with given content in dir
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestWalkPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"a.txt":         "a",
+		"b.go":          "b",
+		"sub/c.txt":     "c",
+		"sub/d.go":      "d",
+		"sub/deep/e.go": "e",
+	})
+
+	testCases := []struct {
+		name     string
+		opt      options
+		paths    []string
+		expected []string
+		isError  bool
+	}{
+		{
+			name:     "files",
+			paths:    []string{"b.go", "a.txt"},
+			expected: []string{"b.go", "a.txt"},
+		},
+		{
+			name:     "directory without recursion",
+			paths:    []string{"sub", "a.txt"},
+			expected: []string{"a.txt"},
+			isError:  true,
+		},
+		{
+			name:     "missing file",
+			paths:    []string{"x.txt", "a.txt"},
+			expected: []string{"a.txt"},
+			isError:  true,
+		},
+		{
+			name:     "recursive",
+			opt:      options{isRecursive: true},
+			paths:    []string{"."},
+			expected: []string{"a.txt", "b.go", "sub/c.txt", "sub/d.go", "sub/deep/e.go"},
+		},
+		{
+			name:     "recursive include",
+			opt:      options{isRecursive: true, includeGlobs: []string{"*.go"}},
+			paths:    []string{"sub", "a.txt"},
+			expected: []string{"sub/d.go", "sub/deep/e.go"},
+		},
+		{
+			name:     "recursive exclude",
+			opt:      options{isRecursive: true, excludeGlobs: []string{"*.go", "c.*"}},
+			paths:    []string{"."},
+			expected: []string{"a.txt"},
+		},
+	}
+
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt := tc.opt
+			opt.paths = tc.paths
+
+			res := []string{}
+			isError := false
+			walkPaths(&opt, func(path string, err error) bool {
+				if err != nil {
+					isError = true
+					return true
+				}
+				res = append(res, filepath.ToSlash(path))
+				return true
+			})
+			assert.Equal(t, tc.expected, res)
+			assert.Equal(t, tc.isError, isError)
+		})
+	}
+
+	t.Run("stop", func(t *testing.T) {
+		opt := options{isRecursive: true, paths: []string{".", "a.txt"}}
+		res := []string{}
+		walkPaths(&opt, func(path string, err error) bool {
+			res = append(res, filepath.ToSlash(path))
+			return len(res) < 2
+		})
+		assert.Equal(t, []string{"a.txt", "b.go"}, res)
+	})
+}
+
+func TestSearchInput(t *testing.T) {
+	text := "alpha\nbeta\ngamma\n"
+	letters := "a\nb\nc\nd\ne\nf\ng\n"
+	binary := "alpha\x00\nbeta\n"
+
+	testCases := []struct {
+		name     string
+		opt      options
+		data     string
+		expected string
+	}{
+		{
+			name:     "filename prefix",
+			opt:      options{patterns: []string{"a$"}, isPrintFilename: true},
+			data:     text,
+			expected: "f.txt:alpha\nf.txt:beta\nf.txt:gamma\n",
+		},
+		{
+			name:     "filename prefix with count",
+			opt:      options{patterns: []string{"^b"}, isPrintFilename: true, isPrintMatchCount: true},
+			data:     text,
+			expected: "f.txt:1\n",
+		},
+		{
+			name:     "filename prefix with context",
+			opt:      options{patterns: []string{"gamma"}, isPrintFilename: true, isPrintLineNum: true, numLinesBeforeMatch: 1},
+			data:     text,
+			expected: "f.txt-2-beta\nf.txt:3:gamma\n",
+		},
+		{
+			name:     "invert count",
+			opt:      options{patterns: []string{"^b"}, isInvertSearch: true, isPrintMatchCount: true},
+			data:     text,
+			expected: "2\n",
+		},
+		{
+			name:     "invert after context",
+			opt:      options{patterns: []string{"[bd]"}, isInvertSearch: true, numLinesAfterMatch: 1, isPrintLineNum: true},
+			data:     letters,
+			expected: "1:a\n2-b\n3:c\n4-d\n5:e\n6:f\n7:g\n",
+		},
+		{
+			name:     "invert before context",
+			opt:      options{patterns: []string{"c"}, isInvertSearch: true, numLinesBeforeMatch: 1, isPrintLineNum: true},
+			data:     "a\nb\nc\nd\n",
+			expected: "1:a\n2:b\n3-c\n4:d\n",
+		},
+		{
+			name:     "invert context with group separator",
+			opt:      options{patterns: []string{"[b-f]"}, isInvertSearch: true, numLinesBeforeMatch: 1, numLinesAfterMatch: 1, isPrintLineNum: true},
+			data:     letters,
+			expected: "1:a\n2-b\n--\n6-f\n7:g\n",
+		},
+		{
+			name:     "invert count ignores context",
+			opt:      options{patterns: []string{"[bd]"}, isInvertSearch: true, numLinesBeforeMatch: 1, numLinesAfterMatch: 1, isPrintMatchCount: true},
+			data:     letters,
+			expected: "5\n",
+		},
+		{
+			name:     "invert count with max count",
+			opt:      options{patterns: []string{"[bd]"}, isInvertSearch: true, isPrintMatchCount: true, hasMaxCount: true, maxCount: 2},
+			data:     letters,
+			expected: "2\n",
+		},
+		{
+			name:     "invert max count with context",
+			opt:      options{patterns: []string{"[ab]"}, isInvertSearch: true, hasMaxCount: true, maxCount: 1, numLinesAfterMatch: 2, isPrintLineNum: true},
+			data:     letters,
+			expected: "3:c\n",
+		},
+		{
+			name:     "quiet",
+			opt:      options{patterns: []string{"a"}, isQuiet: true, isPrintMatchCount: true, isListMatching: true},
+			data:     letters,
+			expected: "",
+		},
+		{
+			name:     "only matching with line numbers and offsets",
+			opt:      options{patterns: []string{"a[lm]"}, isOnlyMatching: true, isPrintLineNum: true, isPrintByteOffset: true},
+			data:     text,
+			expected: "1:0:al\n3:12:am\n",
+		},
+		{
+			name:     "only matching ignores context",
+			opt:      options{patterns: []string{"ta"}, isOnlyMatching: true, numLinesBeforeMatch: 1, isPrintFilename: true},
+			data:     text,
+			expected: "f.txt:ta\n",
+		},
+		{
+			name:     "byte offset of lines with crlf",
+			opt:      options{patterns: []string{"a$"}, isPrintByteOffset: true},
+			data:     "alpha\r\nbeta\r\n",
+			expected: "0:alpha\n7:beta\n",
+		},
+		{
+			name:     "color",
+			opt:      options{patterns: []string{"e"}, isPrintFilename: true, colors: colors{selectedMatch: "31", filename: "35", separator: "36"}},
+			data:     text,
+			expected: "\x1b[35m\x1b[Kf.txt\x1b[m\x1b[K\x1b[36m\x1b[K:\x1b[m\x1b[Kb\x1b[31m\x1b[Ke\x1b[m\x1b[Kta\n",
+		},
+		{
+			name:     "list matching",
+			opt:      options{patterns: []string{"beta"}, isListMatching: true},
+			data:     text,
+			expected: "f.txt\n",
+		},
+		{
+			name:     "list matching without match",
+			opt:      options{patterns: []string{"delta"}, isListMatching: true},
+			data:     text,
+			expected: "",
+		},
+		{
+			name:     "list non matching",
+			opt:      options{patterns: []string{"delta"}, isListNonMatching: true},
+			data:     text,
+			expected: "f.txt\n",
+		},
+		{
+			name:     "max count",
+			opt:      options{patterns: []string{"a"}, hasMaxCount: true, maxCount: 2},
+			data:     "a1\nb\na2\na3\n",
+			expected: "a1\na2\n",
+		},
+		{
+			name:     "max count prints trailing context up to selected line",
+			opt:      options{patterns: []string{"a"}, hasMaxCount: true, maxCount: 1, numLinesAfterMatch: 3, isPrintLineNum: true},
+			data:     "a1\nb\nc\na2\nd\n",
+			expected: "1:a1\n2-b\n3-c\n",
+		},
+		{
+			name:     "max count with count",
+			opt:      options{patterns: []string{"a"}, hasMaxCount: true, maxCount: 2, isPrintMatchCount: true},
+			data:     "a1\na2\na3\n",
+			expected: "2\n",
+		},
+		{
+			name:     "zero max count",
+			opt:      options{patterns: []string{"a"}, hasMaxCount: true},
+			data:     "a1\n",
+			expected: "",
+		},
+		{
+			name:     "binary file matches",
+			opt:      options{patterns: []string{"beta"}, binaryFiles: binaryFilesBinary},
+			data:     binary,
+			expected: "Binary file f.txt matches\n",
+		},
+		{
+			name:     "binary file without match",
+			opt:      options{patterns: []string{"delta"}, binaryFiles: binaryFilesBinary},
+			data:     binary,
+			expected: "",
+		},
+		{
+			name:     "binary file as text",
+			opt:      options{patterns: []string{"beta"}, binaryFiles: binaryFilesText},
+			data:     binary,
+			expected: "beta\n",
+		},
+		{
+			name:     "binary file skipped",
+			opt:      options{patterns: []string{"beta"}, binaryFiles: binaryFilesWithoutMatch},
+			data:     binary,
+			expected: "",
+		},
+		{
+			name:     "skipped binary file is listed as non matching",
+			opt:      options{patterns: []string{"beta"}, binaryFiles: binaryFilesWithoutMatch, isListNonMatching: true},
+			data:     binary,
+			expected: "f.txt\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			in := input{name: "f.txt", reader: io.NopCloser(strings.NewReader(tc.data))}
+			var out bytes.Buffer
+			_, err := searchInput(context.Background(), compileMatcher(t, &tc.opt), in, &out, &groupSeparator{})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestNewOptionsDefaultPaths(t *testing.T) {
+	opt, err := newOptions([]string{"a"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{stdinPath}, opt.paths)
+
+	opt, err = newOptions([]string{"-r", "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{workDirPath}, opt.paths)
+}
+
+func TestFileErrorMessage(t *testing.T) {
+	_, err := os.Stat("nofile")
+	assert.Equal(t, "grep: nofile: no such file or directory", fileErrorMessage("nofile", err))
+	assert.Equal(t, "grep: dir: is a directory", fileErrorMessage("dir", errIsDirectory))
+	assert.Equal(t, "grep: (standard input): EOF", fileErrorMessage(stdinPath, io.EOF))
+}
+
+func TestNewOptionsFilename(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	writeTestFiles(t, dir, map[string]string{"a.txt": "a"})
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected bool
+	}{
+		{name: "single file", args: []string{"a", a}, expected: false},
+		{name: "several files", args: []string{"a", a, a}, expected: true},
+		{name: "recursive", args: []string{"-r", "a", dir}, expected: true},
+		{name: "force filename", args: []string{"-H", "a", a}, expected: true},
+		{name: "no filename", args: []string{"-h", "a", a, a}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt, err := newOptions(tc.args)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, opt.isPrintFilename)
+		})
+	}
+}
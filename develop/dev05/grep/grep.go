@@ -1,12 +1,20 @@
 package grep
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
-	"regexp"
-	"sort"
-	"strings"
+	"os/signal"
+)
+
+// exit statuses of grep
+const (
+	exitSelected    = 0
+	exitNotSelected = 1
+	exitError       = 2
 )
 
 //ExecuteCLI executes grep command with arguments
@@ -14,107 +22,294 @@ func ExecuteCLI(args []string) int {
 	opt, err := newOptions(args)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		return 2
+		return exitError
 	}
 
-	if err := run(opt); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	isSelected, err := run(ctx, opt)
+	// errors of files are reported by run, they only set exit status
+	if err != nil && !errors.Is(err, errBadOpenFile) {
 		fmt.Fprintln(os.Stderr, err)
-		return 1
 	}
+	// like GNU grep with PCRE, a line that can't be matched is an error,
+	// so it isn't mistaken for a line that doesn't match
+	if isLimitExceeded(opt.matcher) {
+		fmt.Fprintln(os.Stderr, errBacktrackLimit)
+		if err == nil {
+			err = errBacktrackLimit
+		}
+	}
+	return exitStatus(opt, isSelected, err)
+}
+
+// exitStatus returns status like grep does: error status has priority
+// over selected lines unless output is quiet
+func exitStatus(opt *options, isSelected bool, err error) int {
+	switch {
+	case isSelected && (err == nil || opt.isQuiet):
+		return exitSelected
+	case err != nil:
+		return exitError
+	}
+	return exitNotSelected
+}
+
+// run searches files and reports whether any line is selected
+func run(ctx context.Context, opt *options) (bool, error) {
+	w := bufio.NewWriter(os.Stdout)
+	errs := &errorReporter{isSilent: opt.isSilent}
+
+	var stats searchStats
+	if opt.parallel > 1 {
+		stats = searchParallel(ctx, opt, w, errs)
+	} else {
+		stats = searchSequential(ctx, opt, w, errs)
+	}
+	switch {
+	case opt.isQuiet:
+	case opt.isJSON:
+		printJSONSummary(w, stats)
+	case opt.isPrintTotalCount():
+		prefix := paint(opt.colors.filename, totalName) + paint(opt.colors.separator, matchSep)
+		printCount(w, prefix, stats.matchedLines)
+	}
+
+	err := errs.err
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	return stats.matchedLines > 0, err
+}
+
+// searchStats are totals of searched files
+type searchStats struct {
+	searches          int
+	searchesWithMatch int
+	matchedLines      int
+}
+
+// add adds file with count selected lines
+func (s *searchStats) add(count int) {
+	s.searches++
+	if count > 0 {
+		s.searchesWithMatch++
+	}
+	s.matchedLines += count
+}
 
-	return 0
+// searchSequential searches files one by one writing results as soon as
+// they are found. In quiet mode search stops at the first selected line.
+func searchSequential(ctx context.Context, opt *options, w *bufio.Writer, errs *errorReporter) searchStats {
+	var stats searchStats
+	groups := &groupSeparator{}
+	walkPaths(opt, func(path string, err error) bool {
+		count := 0
+		if err == nil {
+			count, err = searchPath(ctx, opt, path, w, groups)
+		}
+		if err != nil {
+			errs.report(path, err)
+			return true
+		}
+		stats.add(count)
+		return !(opt.isQuiet && count > 0)
+	})
+	return stats
 }
 
-func run(opt *options) error {
-	data, err := readData(opt.reader)
+// searchPath searches file and returns the number of selected lines
+func searchPath(ctx context.Context, opt *options, path string, w *bufio.Writer, groups *groupSeparator) (int, error) {
+	in, err := openInput(path)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	defer in.reader.Close()
 
-	matches := findMatchedLineIndices(opt, data)
+	in.reader = flushingReader{ReadCloser: in.reader, w: w}
+	if opt.isSearchZip {
+		if in.reader, err = openDecompressed(in.reader); err != nil {
+			return 0, err
+		}
+		defer in.reader.Close()
+	}
+	return searchInput(ctx, opt, in, w, groups)
+}
+
+// searchInput reads the input line by line and writes selected lines
+// with context to w. Reading stops as soon as the result is known.
+// Groups of lines are separated from groups of previous files.
+// Returns the number of selected lines.
+func searchInput(ctx context.Context, opt *options, in input, w io.Writer, groups *groupSeparator) (int, error) {
+	r := bufio.NewReaderSize(in.reader, binaryPeekSize)
+	isBinary := opt.binaryFiles != binaryFilesText && isBinaryData(r)
+	isSkipped := isBinary && opt.binaryFiles == binaryFilesWithoutMatch
+	isPrintLines := !opt.isQuiet && !opt.isListMatching && !opt.isListNonMatching && !opt.isPrintMatchCount && !isBinary
+	isStopAtMatch := opt.isQuiet || opt.isListMatching || opt.isListNonMatching || (isBinary && !opt.isPrintMatchCount)
 
-	if opt.isPrintMatchCount {
-		printCount(len(matches))
-		return nil
+	filename := ""
+	if opt.isPrintFilename {
+		filename = in.name
+	}
+	var printer *contextPrinter
+	var jp *jsonPrinter
+	if isPrintLines {
+		printer = newContextPrinter(w, opt, filename, opt.matcher)
+		printer.groups = groups
+	}
+	if opt.isJSON {
+		jp = newJSONPrinter(w, in.name)
+		if printer != nil {
+			printer.emit = jp.emitFunc(opt.matcher)
+		}
 	}
 
-	before := opt.numLinesBeforeMatch
-	after := opt.numLinesAfterMatch
-	total := len(data)
-	print := getLineIndicesToPrint(before, after, total, matches)
+	count := 0
+	if !isSkipped {
+		var err error
+		if count, err = scanLines(ctx, opt, r, printer, isStopAtMatch); err != nil {
+			return count, err
+		}
+	}
 
-	printData(data, print, opt.isPrintLineNum)
+	switch {
+	case opt.isQuiet:
+	case opt.isJSON:
+		jp.end(count, isBinary)
+	case opt.isListMatching:
+		if count > 0 {
+			fmt.Fprintln(w, paint(opt.colors.filename, in.name))
+		}
+	case opt.isListNonMatching:
+		if count == 0 {
+			fmt.Fprintln(w, paint(opt.colors.filename, in.name))
+		}
+	case opt.isPrintMatchCount:
+		prefix := ""
+		if filename != "" {
+			prefix = paint(opt.colors.filename, filename) + paint(opt.colors.separator, matchSep)
+		}
+		printCount(w, prefix, count)
+	case isBinary && count > 0:
+		fmt.Fprintf(w, "Binary file %s matches\n", in.name)
+	}
 
-	return nil
+	return count, nil
 }
 
-func getLineIndicesToPrint(before, after, total int, matches []int) []int {
-	k := len(matches) / 3
-	m := make(map[int]struct{})
-	out := make([]int, 0, k)
+// scanLines reads lines of r and passes them to printer, if it is set.
+// Reading stops after max count of selected lines and their trailing
+// context, at the first selected line if isStopAtMatch is set or when
+// printer fails. Returns the number of selected lines.
+func scanLines(ctx context.Context, opt *options, r io.Reader, printer *contextPrinter, isStopAtMatch bool) (int, error) {
+	isSelected := newLineSelector(opt.matcher, opt.isInvertSearch)
+	scanner := newLineScanner(ctx, r)
 
-	for _, n := range matches {
-		lower, upper, _ := getIndexRangeBounds(before, after, total, n)
-		for i := lower; i <= upper; i++ {
-			if _, has := m[i]; !has {
-				m[i] = struct{}{}
-				out = append(out, i)
+	count := 0
+	for !opt.isMaxCountReached(count) && scanner.Scan() {
+		l := scanner.line
+		selected := isSelected(l.text)
+		if selected {
+			count++
+		}
+		if printer != nil {
+			if err := printer.add(l, selected); err != nil {
+				return count, err
 			}
 		}
+		if selected && isStopAtMatch {
+			return count, nil
+		}
 	}
 
-	sort.Ints(out)
-	return out
+	// like grep, after max count is reached trailing context is printed
+	// up to the next selected line
+	if printer != nil && opt.isMaxCountReached(count) {
+		for printer.hasPendingContext() && scanner.Scan() {
+			l := scanner.line
+			if isSelected(l.text) {
+				break
+			}
+			if err := printer.add(l, false); err != nil {
+				return count, err
+			}
+		}
+	}
+	return count, scanner.Err()
+}
+
+// ctxCheckInterval is the number of lines read between checks
+// of context cancellation
+const ctxCheckInterval = 1024
+
+// lineScanner reads numbered lines and tracks their byte offsets.
+// Scanning stops with error when context is canceled.
+type lineScanner struct {
+	ctx      context.Context
+	scanner  *bufio.Scanner
+	line     numberedLine
+	lineSize int
+	offset   int64
+	err      error
 }
 
-func getIndexRangeBounds(before, after, total, current int) (int, int, error) {
-	if (current > total) || (current < 0) || (before < 0) || (after < 0) || (total < 1) {
-		return 0, 0, errors.New("wrong bounds configuration")
+func newLineScanner(ctx context.Context, r io.Reader) *lineScanner {
+	s := &lineScanner{ctx: ctx, scanner: bufio.NewScanner(r)}
+	s.scanner.Buffer(nil, maxLineSize)
+	s.scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if token != nil {
+			s.lineSize = advance
+		}
+		return advance, token, err
+	})
+	return s
+}
+
+func (s *lineScanner) Scan() bool {
+	if (s.line.num+1)%ctxCheckInterval == 0 {
+		if s.err = s.ctx.Err(); s.err != nil {
+			return false
+		}
 	}
-	lower := maxInt(current-before, 0)
-	upper := minInt(current+after, total-1)
-	return lower, upper, nil
+	if !s.scanner.Scan() {
+		return false
+	}
+	s.line = numberedLine{num: s.line.num + 1, offset: s.offset, text: s.scanner.Text()}
+	s.offset += int64(s.lineSize)
+	return true
 }
 
-func findMatchedLineIndices(opt *options, data []string) []int {
-	invert := opt.isInvertSearch
-	igoreCase := opt.isIgnoreCase
-	isExact := opt.isExactMatch
-	if isExact {
-		return findMatchedLineIndicesExact(data, opt.GetExactPattern(), igoreCase, invert)
+func (s *lineScanner) Err() error {
+	if s.err != nil {
+		return s.err
 	}
-	return findMatchedLineIndicesRegexp(data, opt.GetRegExpPattern(), invert)
+	return s.scanner.Err()
 }
 
-func isMatch(contains, invert bool) bool {
-	return (contains && !invert) || (!contains && invert)
+// flushingReader flushes output before every read of input, so selected
+// lines are written as soon as reading blocks, e.g. while following a log
+type flushingReader struct {
+	io.ReadCloser
+	w *bufio.Writer
 }
 
-func findMatchedLineIndicesExact(data []string, pattern string, ignoreCase, invert bool) []int {
-	out := make([]int, 0, len(data)/2)
-	if ignoreCase {
-		pattern = strings.ToLower(pattern)
+func (r flushingReader) Read(p []byte) (int, error) {
+	if err := r.w.Flush(); err != nil {
+		return 0, err
 	}
+	return r.ReadCloser.Read(p)
+}
 
-	for i, s := range data {
-		if ignoreCase {
-			s = strings.ToLower(s)
-		}
-		cont := strings.Contains(s, pattern)
-		if isMatch(cont, invert) {
-			out = append(out, i)
-		}
+// newLineSelector returns function that reports whether line is selected:
+// it matches the pattern or doesn't match it in invert search
+func newLineSelector(m matcher, invert bool) func(string) bool {
+	return func(s string) bool {
+		return isMatch(m.isMatch(s), invert)
 	}
-	return out
 }
 
-func findMatchedLineIndicesRegexp(data []string, r *regexp.Regexp, invert bool) []int {
-	out := make([]int, 0, len(data)/2)
-	for i, s := range data {
-		cont := r.MatchString(s)
-		if isMatch(cont, invert) {
-			out = append(out, i)
-		}
-	}
-	return out
+func isMatch(contains, invert bool) bool {
+	return (contains && !invert) || (!contains && invert)
 }
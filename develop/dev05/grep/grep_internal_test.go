@@ -1,11 +1,21 @@
 package grep
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// compileMatcher sets matcher of options like newOptions does
+func compileMatcher(t *testing.T, opt *options) *options {
+	m, err := newMatcher(opt)
+	assert.NoError(t, err)
+	opt.matcher = m
+	return opt
+}
+
 func TestIsStringMatches(t *testing.T) {
 
 	testCases := []struct {
@@ -18,7 +28,7 @@ func TestIsStringMatches(t *testing.T) {
 			name: "exact not ignore case (1)",
 			data: []string{"aa bbb dd CCC"},
 			opt: &options{
-				pattern:      "dd",
+				patterns:     []string{"dd"},
 				isExactMatch: true,
 				isIgnoreCase: false,
 			},
@@ -28,7 +38,7 @@ func TestIsStringMatches(t *testing.T) {
 			name: "exact not ignore case (2)",
 			data: []string{"aa bbb dd CCC"},
 			opt: &options{
-				pattern:      "cc",
+				patterns:     []string{"cc"},
 				isExactMatch: true,
 				isIgnoreCase: false,
 			},
@@ -38,7 +48,7 @@ func TestIsStringMatches(t *testing.T) {
 			name: "exact ignore case (1)",
 			data: []string{"aa bbb dd CCC"},
 			opt: &options{
-				pattern:      "cc",
+				patterns:     []string{"cc"},
 				isExactMatch: true,
 				isIgnoreCase: true,
 			},
@@ -48,7 +58,7 @@ func TestIsStringMatches(t *testing.T) {
 			name: "regexp not ignore case (1)",
 			data: []string{"aa bbb dd CCC"},
 			opt: &options{
-				pattern:      "[b-b]",
+				patterns:     []string{"[b-b]"},
 				isExactMatch: false,
 				isIgnoreCase: false,
 			},
@@ -58,7 +68,7 @@ func TestIsStringMatches(t *testing.T) {
 			name: "regexp not ignore case (2)",
 			data: []string{"aa bbb dd CCC"},
 			opt: &options{
-				pattern:      "[B-B]",
+				patterns:     []string{"[B-B]"},
 				isExactMatch: false,
 				isIgnoreCase: false,
 			},
@@ -68,7 +78,7 @@ func TestIsStringMatches(t *testing.T) {
 			name: "regexp ignore case (1)",
 			data: []string{"aa bbb dd CCC"},
 			opt: &options{
-				pattern:      "[B-B]",
+				patterns:     []string{"[B-B]"},
 				isExactMatch: false,
 				isIgnoreCase: true,
 			},
@@ -78,7 +88,7 @@ func TestIsStringMatches(t *testing.T) {
 			name: "regexp ivnert search (1)",
 			data: []string{"aa bbb dd CCC"},
 			opt: &options{
-				pattern:        "[B-B]",
+				patterns:       []string{"[B-B]"},
 				isExactMatch:   false,
 				isIgnoreCase:   false,
 				isInvertSearch: true,
@@ -89,59 +99,125 @@ func TestIsStringMatches(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			res := findMatchedLineIndices(tc.opt, tc.data)
-			assert.Equal(t, tc.expected, len(res) == len(tc.data))
+			opt := compileMatcher(t, tc.opt)
+			isSelected := newLineSelector(opt.matcher, opt.isInvertSearch)
+			res := true
+			for _, s := range tc.data {
+				res = res && isSelected(s)
+			}
+			assert.Equal(t, tc.expected, res)
 		})
 	}
 }
 
-func TestGetLineIndicesToPrint(t *testing.T) {
-
+func TestContextPrinter(t *testing.T) {
 	testCases := []struct {
 		name     string
-		data     []int
+		total    int
+		matches  []int
 		before   int
 		after    int
-		total    int
-		expected []int
+		expected []string
 	}{
 		{
 			name:     "basic",
-			data:     []int{5, 10},
+			total:    100,
+			matches:  []int{5, 10},
 			before:   2,
 			after:    2,
-			total:    100,
-			expected: []int{3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+			expected: []string{"3-", "4-", "5:", "6-", "7-", "8-", "9-", "10:", "11-", "12-"},
 		},
 		{
 			name:     "basic 2",
-			data:     []int{5, 10},
+			total:    100,
+			matches:  []int{5, 10},
 			before:   1,
 			after:    1,
-			total:    100,
-			expected: []int{4, 5, 6, 9, 10, 11},
+			expected: []string{"4-", "5:", "6-", "--", "9-", "10:", "11-"},
 		},
 		{
 			name:     "full range",
-			data:     []int{5, 10},
+			total:    10,
+			matches:  []int{5, 10},
 			before:   10,
 			after:    10,
-			total:    10,
-			expected: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+			expected: []string{"1-", "2-", "3-", "4-", "5:", "6-", "7-", "8-", "9-", "10:"},
 		},
 		{
 			name:     "current only",
-			data:     []int{10},
-			before:   0,
-			after:    0,
 			total:    100,
-			expected: []int{10},
+			matches:  []int{10, 12},
+			expected: []string{"10:", "12:"},
+		},
+		{
+			name:     "after only",
+			total:    100,
+			matches:  []int{1, 2, 50},
+			after:    1,
+			expected: []string{"1:", "2:", "3-", "--", "50:", "51-"},
+		},
+		{
+			name:     "before at start",
+			total:    5,
+			matches:  []int{2},
+			before:   3,
+			expected: []string{"1-", "2:"},
 		},
 	}
+
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			res := getLineIndicesToPrint(tc.before, tc.after, tc.total, tc.data)
+			opt := &options{
+				numLinesBeforeMatch: tc.before,
+				numLinesAfterMatch:  tc.after,
+				isPrintLineNum:      true,
+			}
+			var out bytes.Buffer
+			p := newContextPrinter(&out, opt, "", compileMatcher(t, opt).matcher)
+
+			matches := make(map[int]bool)
+			for _, v := range tc.matches {
+				matches[v] = true
+			}
+			for i := 1; i <= tc.total; i++ {
+				p.add(numberedLine{num: i, text: ""}, matches[i])
+			}
+
+			res := strings.Fields(out.String())
 			assert.Equal(t, tc.expected, res)
 		})
 	}
 }
+
+func TestContextPrinterFilename(t *testing.T) {
+	opt := &options{numLinesBeforeMatch: 1, isPrintLineNum: true}
+	var out bytes.Buffer
+	p := newContextPrinter(&out, opt, "f.txt", compileMatcher(t, opt).matcher)
+	p.add(numberedLine{num: 1, text: "a"}, false)
+	p.add(numberedLine{num: 2, text: "b"}, true)
+	assert.Equal(t, "f.txt-1-a\nf.txt:2:b\n", out.String())
+}
+
+func TestExitStatus(t *testing.T) {
+	testCases := []struct {
+		name       string
+		opt        options
+		isSelected bool
+		err        error
+		expected   int
+	}{
+		{name: "selected", isSelected: true, expected: exitSelected},
+		{name: "not selected", expected: exitNotSelected},
+		{name: "error", err: errBadOpenFile, expected: exitError},
+		{name: "selected with error", isSelected: true, err: errBadOpenFile, expected: exitError},
+		{name: "quiet selected with error", opt: options{isQuiet: true}, isSelected: true, err: errBadOpenFile, expected: exitSelected},
+		{name: "quiet not selected with error", opt: options{isQuiet: true}, err: errBadOpenFile, expected: exitError},
+		{name: "backtracking limit", err: errBacktrackLimit, expected: exitError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, exitStatus(&tc.opt, tc.isSelected, tc.err))
+		})
+	}
+}
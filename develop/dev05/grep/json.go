@@ -0,0 +1,148 @@
+package grep
+
+import (
+	"encoding/json"
+	"io"
+	"unicode/utf8"
+)
+
+// types of JSON messages
+const (
+	jsonBegin   = "begin"
+	jsonMatch   = "match"
+	jsonContext = "context"
+	jsonEnd     = "end"
+	jsonSummary = "summary"
+)
+
+// jsonPrinter writes results of a file as JSON lines like ripgrep does:
+// messages of selected and context lines are wrapped by begin and end
+// messages, files without selected lines have no messages
+type jsonPrinter struct {
+	enc     *json.Encoder
+	path    string
+	isBegun bool
+}
+
+type jsonMessage struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// jsonText is a string, it is encoded in base64 as bytes if it isn't
+// valid UTF-8
+type jsonText string
+
+func (t jsonText) MarshalJSON() ([]byte, error) {
+	if utf8.ValidString(string(t)) {
+		return json.Marshal(struct {
+			Text string `json:"text"`
+		}{string(t)})
+	}
+	return json.Marshal(struct {
+		Bytes []byte `json:"bytes"`
+	}{[]byte(t)})
+}
+
+type jsonBeginData struct {
+	Path jsonText `json:"path"`
+}
+
+type jsonLineData struct {
+	Path           jsonText       `json:"path"`
+	Lines          jsonText       `json:"lines"`
+	LineNumber     int            `json:"line_number"`
+	AbsoluteOffset int64          `json:"absolute_offset"`
+	Submatches     []jsonSubmatch `json:"submatches"`
+}
+
+// jsonSubmatch is a match in the line, bounds are byte offsets
+type jsonSubmatch struct {
+	Match jsonText `json:"match"`
+	Start int      `json:"start"`
+	End   int      `json:"end"`
+}
+
+type jsonEndData struct {
+	Path     jsonText      `json:"path"`
+	IsBinary bool          `json:"binary"`
+	Stats    jsonFileStats `json:"stats"`
+}
+
+type jsonFileStats struct {
+	MatchedLines int `json:"matched_lines"`
+}
+
+type jsonSummaryData struct {
+	Stats jsonSummaryStats `json:"stats"`
+}
+
+type jsonSummaryStats struct {
+	Searches          int `json:"searches"`
+	SearchesWithMatch int `json:"searches_with_match"`
+	MatchedLines      int `json:"matched_lines"`
+}
+
+func newJSONPrinter(w io.Writer, path string) *jsonPrinter {
+	return &jsonPrinter{enc: json.NewEncoder(w), path: path}
+}
+
+// line writes selected or context line with matches
+func (p *jsonPrinter) line(kind string, l numberedLine, matches [][]int) {
+	p.begin()
+	submatches := make([]jsonSubmatch, len(matches))
+	for i, m := range matches {
+		submatches[i] = jsonSubmatch{Match: jsonText(l.text[m[0]:m[1]]), Start: m[0], End: m[1]}
+	}
+	p.enc.Encode(jsonMessage{Type: kind, Data: jsonLineData{
+		Path:           jsonText(p.path),
+		Lines:          jsonText(l.text),
+		LineNumber:     l.num,
+		AbsoluteOffset: l.offset,
+		Submatches:     submatches,
+	}})
+}
+
+// emitFunc returns function that writes lines of context printer
+func (p *jsonPrinter) emitFunc(m matcher) func(l numberedLine, isContext bool) error {
+	return func(l numberedLine, isContext bool) error {
+		if isContext {
+			p.line(jsonContext, l, nil)
+			return nil
+		}
+		p.line(jsonMatch, l, m.findAll(l.text))
+		return nil
+	}
+}
+
+// end writes end message if file has selected lines
+func (p *jsonPrinter) end(count int, isBinary bool) {
+	if count == 0 {
+		return
+	}
+	p.begin()
+	p.enc.Encode(jsonMessage{Type: jsonEnd, Data: jsonEndData{
+		Path:     jsonText(p.path),
+		IsBinary: isBinary,
+		Stats:    jsonFileStats{MatchedLines: count},
+	}})
+}
+
+func (p *jsonPrinter) begin() {
+	if p.isBegun {
+		return
+	}
+	p.isBegun = true
+	p.enc.Encode(jsonMessage{Type: jsonBegin, Data: jsonBeginData{Path: jsonText(p.path)}})
+}
+
+// printJSONSummary writes the last message with totals of all files
+func printJSONSummary(w io.Writer, stats searchStats) {
+	json.NewEncoder(w).Encode(jsonMessage{Type: jsonSummary, Data: jsonSummaryData{
+		Stats: jsonSummaryStats{
+			Searches:          stats.searches,
+			SearchesWithMatch: stats.searchesWithMatch,
+			MatchedLines:      stats.matchedLines,
+		},
+	}})
+}
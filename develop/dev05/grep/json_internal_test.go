@@ -0,0 +1,81 @@
+package grep
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchInputJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opt      options
+		data     string
+		expected []string
+	}{
+		{
+			name: "match and context",
+			opt:  options{patterns: []string{"b+"}, numLinesBeforeMatch: 1},
+			data: "a\nxbbyb\nc\n",
+			expected: []string{
+				`{"type":"begin","data":{"path":{"text":"f.txt"}}}`,
+				`{"type":"context","data":{"path":{"text":"f.txt"},"lines":{"text":"a"},"line_number":1,"absolute_offset":0,"submatches":[]}}`,
+				`{"type":"match","data":{"path":{"text":"f.txt"},"lines":{"text":"xbbyb"},"line_number":2,"absolute_offset":2,` +
+					`"submatches":[{"match":{"text":"bb"},"start":1,"end":3},{"match":{"text":"b"},"start":4,"end":5}]}}`,
+				`{"type":"end","data":{"path":{"text":"f.txt"},"binary":false,"stats":{"matched_lines":1}}}`,
+			},
+		},
+		{
+			name: "invalid utf-8 as bytes",
+			opt:  options{patterns: []string{"b"}, binaryFiles: binaryFilesText},
+			data: "b\xff\n",
+			expected: []string{
+				`{"type":"begin","data":{"path":{"text":"f.txt"}}}`,
+				`{"type":"match","data":{"path":{"text":"f.txt"},"lines":{"bytes":"Yv8="},"line_number":1,"absolute_offset":0,` +
+					`"submatches":[{"match":{"text":"b"},"start":0,"end":1}]}}`,
+				`{"type":"end","data":{"path":{"text":"f.txt"},"binary":false,"stats":{"matched_lines":1}}}`,
+			},
+		},
+		{
+			name: "binary file",
+			opt:  options{patterns: []string{"b"}, binaryFiles: binaryFilesBinary},
+			data: "\x00b\n",
+			expected: []string{
+				`{"type":"begin","data":{"path":{"text":"f.txt"}}}`,
+				`{"type":"end","data":{"path":{"text":"f.txt"},"binary":true,"stats":{"matched_lines":1}}}`,
+			},
+		},
+		{
+			name:     "no match",
+			opt:      options{patterns: []string{"z"}},
+			data:     "a\n",
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opt.isJSON = true
+			in := input{name: "f.txt", reader: io.NopCloser(strings.NewReader(tc.data))}
+			var out bytes.Buffer
+			_, err := searchInput(context.Background(), compileMatcher(t, &tc.opt), in, &out, &groupSeparator{})
+			assert.NoError(t, err)
+
+			res := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			if out.Len() == 0 {
+				res = []string{}
+			}
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestPrintJSONSummary(t *testing.T) {
+	var out bytes.Buffer
+	printJSONSummary(&out, searchStats{searches: 3, searchesWithMatch: 2, matchedLines: 5})
+	assert.Equal(t, `{"type":"summary","data":{"stats":{"searches":3,"searches_with_match":2,"matched_lines":5}}}`+"\n", out.String())
+}
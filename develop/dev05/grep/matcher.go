@@ -0,0 +1,348 @@
+package grep
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// matcher finds pattern in lines
+type matcher interface {
+	// isMatch reports whether line contains the pattern
+	isMatch(s string) bool
+	// findAll returns byte bounds of non-overlapping non-empty matches
+	findAll(s string) [][]int
+	// firstFrom returns bounds of leftmost longest non-empty match that
+	// starts at byte pos or later, nil if there is no such match. Anchors
+	// and word boundaries see the whole string.
+	firstFrom(s string, pos int) []int
+	// isWhole reports whether the whole string matches the pattern
+	isWhole(s string) bool
+}
+
+// newMatcher returns matcher of any of patterns, matches are restricted
+// to whole lines or words if options require
+func newMatcher(opt *options) (matcher, error) {
+	m, err := newPatternsMatcher(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case opt.isLineMatch:
+		return &lineMatcher{inner: m}, nil
+	case opt.isWordMatch:
+		return &wordMatcher{inner: m}, nil
+	}
+	return m, nil
+}
+
+func newPatternsMatcher(opt *options) (matcher, error) {
+	patterns := opt.patterns
+	if opt.isPerlRegexp && len(patterns) > 0 {
+		return newBacktrackMatcher(patterns, opt.isIgnoreCase)
+	}
+	if opt.isExactMatch || len(patterns) == 0 {
+		if len(patterns) == 1 {
+			return newFixedMatcher(patterns[0], opt.isIgnoreCase), nil
+		}
+		return newACMatcher(patterns, opt.isIgnoreCase), nil
+	}
+
+	re, err := compileRegexps(patterns, opt.isIgnoreCase, "%s")
+	if err != nil {
+		return nil, err
+	}
+	whole, err := compileRegexps(patterns, opt.isIgnoreCase, "^(?:%s)$")
+	if err != nil {
+		return nil, err
+	}
+	after, err := compileRegexps(patterns, opt.isIgnoreCase, "(?s:.)(%s)")
+	if err != nil {
+		return nil, err
+	}
+	return &regexpMatcher{re: re, whole: whole, after: after}, nil
+}
+
+// compileRegexps combines patterns into alternation, so line is scanned
+// once for all of them, and wraps it with layout. Like grep, regexp prefers
+// leftmost longest match.
+func compileRegexps(patterns []string, isIgnoreCase bool, layout string) (*regexp.Regexp, error) {
+	groups := make([]string, len(patterns))
+	for i, p := range patterns {
+		// every pattern must be valid alone, so it can't break the group
+		if _, err := syntax.Parse(p, syntax.Perl); err != nil {
+			return nil, fmt.Errorf("grep: invalid pattern: %w", err)
+		}
+		groups[i] = "(?:" + p + ")"
+	}
+
+	expr := fmt.Sprintf(layout, strings.Join(groups, "|"))
+	if isIgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("grep: invalid pattern: %w", err)
+	}
+	re.Longest()
+	return re, nil
+}
+
+// regexpMatcher finds regexp, whole is the same regexp anchored to the
+// start and the end of string and after captures the regexp preceded by
+// a rune
+type regexpMatcher struct {
+	re    *regexp.Regexp
+	whole *regexp.Regexp
+	after *regexp.Regexp
+}
+
+func (m *regexpMatcher) isMatch(s string) bool {
+	return m.re.MatchString(s)
+}
+
+func (m *regexpMatcher) findAll(s string) [][]int {
+	out := make([][]int, 0)
+	for _, v := range m.re.FindAllStringIndex(s, -1) {
+		if v[1] > v[0] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func (m *regexpMatcher) firstFrom(s string, pos int) []int {
+	if pos == 0 {
+		if loc := m.re.FindStringIndex(s); loc == nil || loc[1] > loc[0] {
+			return loc
+		}
+		if all := m.findAll(s); len(all) > 0 {
+			return all[0]
+		}
+		return nil
+	}
+
+	// regexp can't start searching in the middle of string, so search
+	// starts at the previous rune that gives context for ^ and \b
+	for pos <= len(s) {
+		_, size := utf8.DecodeLastRuneInString(s[:pos])
+		start := pos - size
+		loc := m.after.FindStringSubmatchIndex(s[start:])
+		if loc == nil {
+			return nil
+		}
+		if loc[3] > loc[2] {
+			return []int{start + loc[2], start + loc[3]}
+		}
+		// the longest match is empty, so no match starts there
+		if start+loc[2] >= len(s) {
+			return nil
+		}
+		_, size = utf8.DecodeRuneInString(s[start+loc[2]:])
+		pos = start + loc[2] + size
+	}
+	return nil
+}
+
+func (m *regexpMatcher) isWhole(s string) bool {
+	return m.whole.MatchString(s)
+}
+
+// fixedMatcher finds single fixed string
+type fixedMatcher struct {
+	pattern      string
+	isIgnoreCase bool
+}
+
+func newFixedMatcher(pattern string, isIgnoreCase bool) *fixedMatcher {
+	if isIgnoreCase {
+		pattern = foldCase(pattern)
+	}
+	return &fixedMatcher{pattern: pattern, isIgnoreCase: isIgnoreCase}
+}
+
+func (m *fixedMatcher) isMatch(s string) bool {
+	if m.isIgnoreCase {
+		s = foldCase(s)
+	}
+	return strings.Contains(s, m.pattern)
+}
+
+func (m *fixedMatcher) findAll(s string) [][]int {
+	out := make([][]int, 0)
+	if m.pattern == "" {
+		return out
+	}
+	if m.isIgnoreCase {
+		s = foldCase(s)
+	}
+
+	for start := 0; start < len(s); {
+		i := strings.Index(s[start:], m.pattern)
+		if i < 0 {
+			break
+		}
+		end := start + i + len(m.pattern)
+		out = append(out, []int{start + i, end})
+		start = end
+	}
+	return out
+}
+
+func (m *fixedMatcher) firstFrom(s string, pos int) []int {
+	if m.pattern == "" {
+		return nil
+	}
+	if m.isIgnoreCase {
+		s = foldCase(s)
+	}
+	if i := strings.Index(s[pos:], m.pattern); i >= 0 {
+		return []int{pos + i, pos + i + len(m.pattern)}
+	}
+	return nil
+}
+
+func (m *fixedMatcher) isWhole(s string) bool {
+	if m.isIgnoreCase {
+		s = foldCase(s)
+	}
+	return s == m.pattern
+}
+
+// lineMatcher matches only whole lines
+type lineMatcher struct {
+	inner matcher
+}
+
+func (m *lineMatcher) isMatch(s string) bool {
+	return m.inner.isWhole(s)
+}
+
+func (m *lineMatcher) findAll(s string) [][]int {
+	if loc := m.firstFrom(s, 0); loc != nil {
+		return [][]int{loc}
+	}
+	return [][]int{}
+}
+
+func (m *lineMatcher) firstFrom(s string, pos int) []int {
+	if pos == 0 && s != "" && m.inner.isWhole(s) {
+		return []int{0, len(s)}
+	}
+	return nil
+}
+
+func (m *lineMatcher) isWhole(s string) bool {
+	return m.inner.isWhole(s)
+}
+
+// wordMatcher matches only whole words: match must not be preceded or
+// followed by a letter, a digit or an underscore. If the longest match
+// at some position is not a word, shorter matches are tried.
+type wordMatcher struct {
+	inner matcher
+}
+
+func (m *wordMatcher) isMatch(s string) bool {
+	return m.next(s, 0) != nil
+}
+
+func (m *wordMatcher) findAll(s string) [][]int {
+	out := make([][]int, 0)
+	for pos := 0; ; {
+		loc := m.next(s, pos)
+		if loc == nil {
+			return out
+		}
+		out = append(out, loc)
+		pos = loc[1]
+	}
+}
+
+func (m *wordMatcher) firstFrom(s string, pos int) []int {
+	return m.next(s, pos)
+}
+
+func (m *wordMatcher) isWhole(s string) bool {
+	return m.inner.isWhole(s)
+}
+
+// next returns the first word match that starts at pos or later
+func (m *wordMatcher) next(s string, pos int) []int {
+	for pos < len(s) {
+		loc := m.inner.firstFrom(s, pos)
+		if loc == nil {
+			return nil
+		}
+		start, end := loc[0], loc[1]
+
+		if isWordStart(s, start) {
+			for ; end > start; end-- {
+				if isWordEnd(s, end) && m.inner.isWhole(s[start:end]) {
+					return []int{start, end}
+				}
+			}
+		}
+
+		_, size := utf8.DecodeRuneInString(s[start:])
+		pos = start + size
+	}
+	return nil
+}
+
+// isLimitExceeded reports whether matcher gave up on some line, such lines
+// are treated as not matching
+func isLimitExceeded(m matcher) bool {
+	switch v := m.(type) {
+	case *lineMatcher:
+		return isLimitExceeded(v.inner)
+	case *wordMatcher:
+		return isLimitExceeded(v.inner)
+	case *backtrackMatcher:
+		return v.isLimitExceeded()
+	}
+	return false
+}
+
+// isWordStart reports whether word can start at byte i of the string
+func isWordStart(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return !isWordRune(r)
+}
+
+// isWordEnd reports whether word can end at byte i of the string
+func isWordEnd(s string, i int) bool {
+	if i == len(s) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return !isWordRune(r)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+// foldCase lowers case of runes that keep their size, so byte offsets
+// in the result are the same as in the source string
+func foldCase(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if l := unicode.ToLower(r); r != utf8.RuneError && utf8.RuneLen(l) == size {
+			b.WriteRune(l)
+		} else {
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
@@ -0,0 +1,384 @@
+package grep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcherFindAll(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opt      options
+		data     string
+		expected [][]int
+	}{
+		{
+			name:     "regexp",
+			opt:      options{patterns: []string{"b+"}},
+			data:     "abbcb",
+			expected: [][]int{{1, 3}, {4, 5}},
+		},
+		{
+			name:     "regexp skips empty matches",
+			opt:      options{patterns: []string{"x*"}},
+			data:     "axxb",
+			expected: [][]int{{1, 3}},
+		},
+		{
+			name:     "regexp ignore case",
+			opt:      options{patterns: []string{"ab"}, isIgnoreCase: true},
+			data:     "xAbaB",
+			expected: [][]int{{1, 3}, {3, 5}},
+		},
+		{
+			name:     "fixed",
+			opt:      options{patterns: []string{"a."}, isExactMatch: true},
+			data:     "a.ba.a",
+			expected: [][]int{{0, 2}, {3, 5}},
+		},
+		{
+			name:     "fixed non overlapping",
+			opt:      options{patterns: []string{"aa"}, isExactMatch: true},
+			data:     "aaaa",
+			expected: [][]int{{0, 2}, {2, 4}},
+		},
+		{
+			name:     "fixed ignore case unicode",
+			opt:      options{patterns: []string{"ПРИВЕТ"}, isExactMatch: true, isIgnoreCase: true},
+			data:     "ой, Привет!",
+			expected: [][]int{{6, 18}},
+		},
+		{
+			name:     "fixed empty pattern",
+			opt:      options{patterns: []string{""}, isExactMatch: true},
+			data:     "abc",
+			expected: [][]int{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := newMatcher(&tc.opt)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, m.findAll(tc.data))
+			assert.Equal(t, len(tc.expected) > 0 || tc.opt.patterns[0] == "", m.isMatch(tc.data))
+		})
+	}
+}
+
+func TestMultiPatternMatcher(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opt      options
+		data     string
+		expected [][]int
+		isMatch  bool
+	}{
+		{
+			name:     "fixed",
+			opt:      options{patterns: []string{"he", "she", "his", "hers"}, isExactMatch: true},
+			data:     "ushers",
+			expected: [][]int{{1, 4}},
+			isMatch:  true,
+		},
+		{
+			name:     "fixed leftmost longest",
+			opt:      options{patterns: []string{"ab", "abcd", "bc", "d"}, isExactMatch: true},
+			data:     "xabcdd",
+			expected: [][]int{{1, 5}, {5, 6}},
+			isMatch:  true,
+		},
+		{
+			name:     "fixed pattern inside another one",
+			opt:      options{patterns: []string{"abcx", "bc"}, isExactMatch: true},
+			data:     "abcy",
+			expected: [][]int{{1, 3}},
+			isMatch:  true,
+		},
+		{
+			name:     "fixed ignore case",
+			opt:      options{patterns: []string{"10.0.0.1", "Ёлка"}, isExactMatch: true, isIgnoreCase: true},
+			data:     "ip 10.0.0.1 ёЛКА",
+			expected: [][]int{{3, 11}, {12, 20}},
+			isMatch:  true,
+		},
+		{
+			name:     "fixed without match",
+			opt:      options{patterns: []string{"abc", "bcd"}, isExactMatch: true},
+			data:     "abdbc",
+			expected: [][]int{},
+		},
+		{
+			name:     "fixed empty pattern matches every line",
+			opt:      options{patterns: []string{"abc", ""}, isExactMatch: true},
+			data:     "xyz",
+			expected: [][]int{},
+			isMatch:  true,
+		},
+		{
+			name:     "no patterns",
+			opt:      options{patterns: []string{}},
+			data:     "xyz",
+			expected: [][]int{},
+		},
+		{
+			name:     "regexps",
+			opt:      options{patterns: []string{"a+", "[0-9]{2}"}},
+			data:     "baa 123",
+			expected: [][]int{{1, 3}, {4, 6}},
+			isMatch:  true,
+		},
+		{
+			name:     "regexps leftmost longest",
+			opt:      options{patterns: []string{"ab", "abc"}},
+			data:     "abcd",
+			expected: [][]int{{0, 3}},
+			isMatch:  true,
+		},
+		{
+			name:     "regexps ignore case",
+			opt:      options{patterns: []string{"x", "Y"}, isIgnoreCase: true},
+			data:     "XyZ",
+			expected: [][]int{{0, 1}, {1, 2}},
+			isMatch:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := newMatcher(&tc.opt)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, m.findAll(tc.data))
+			assert.Equal(t, tc.isMatch, m.isMatch(tc.data))
+		})
+	}
+}
+
+func TestWordAndLineMatcher(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opt      options
+		data     string
+		expected [][]int
+	}{
+		{
+			name:     "fixed word",
+			opt:      options{patterns: []string{"cat"}, isExactMatch: true, isWordMatch: true},
+			data:     "cats cat_ cat, cat",
+			expected: [][]int{{10, 13}, {15, 18}},
+		},
+		{
+			name:     "cyrillic word",
+			opt:      options{patterns: []string{"кот"}, isExactMatch: true, isWordMatch: true},
+			data:     "котик кот",
+			expected: [][]int{{11, 17}},
+		},
+		{
+			name:     "cyrillic word ignore case",
+			opt:      options{patterns: []string{"кот"}, isWordMatch: true, isIgnoreCase: true},
+			data:     "скот, Кот!",
+			expected: [][]int{{10, 16}},
+		},
+		{
+			name:     "word digits and marks are word runes",
+			opt:      options{patterns: []string{"e"}, isExactMatch: true, isWordMatch: true},
+			data:     "e1 e\u0301 e",
+			expected: [][]int{{7, 8}},
+		},
+		{
+			name:     "regexp word tries shorter match",
+			opt:      options{patterns: []string{"ab+"}, isWordMatch: true},
+			data:     "abbbc ab abb",
+			expected: [][]int{{6, 8}, {9, 12}},
+		},
+		{
+			name:     "regexp word with shorter match at the same start",
+			opt:      options{patterns: []string{"foo|foobar"}, isWordMatch: true},
+			data:     "foobarx foo-bar",
+			expected: [][]int{{8, 11}},
+		},
+		{
+			name:     "many fixed words",
+			opt:      options{patterns: []string{"ab", "abc"}, isExactMatch: true, isWordMatch: true},
+			data:     "abcd ab-abc",
+			expected: [][]int{{5, 7}, {8, 11}},
+		},
+		{
+			name:     "regexp word anchored to line start",
+			opt:      options{patterns: []string{"^."}, isWordMatch: true},
+			data:     "a-b",
+			expected: [][]int{{0, 1}},
+		},
+		{
+			name:     "regexp word anchored to line end",
+			opt:      options{patterns: []string{".$"}, isWordMatch: true},
+			data:     "a-b",
+			expected: [][]int{{2, 3}},
+		},
+		{
+			name:     "regexp word boundary sees previous rune",
+			opt:      options{patterns: []string{`\bb`}, isWordMatch: true},
+			data:     "ab-b",
+			expected: [][]int{{3, 4}},
+		},
+		{
+			name:     "regexp word not at boundary sees previous rune",
+			opt:      options{patterns: []string{`\Bb\w*`}, isWordMatch: true},
+			data:     "xb-b-bb",
+			expected: [][]int{},
+		},
+		{
+			name:     "perl word anchored to line start",
+			opt:      options{patterns: []string{"^."}, isWordMatch: true, isPerlRegexp: true},
+			data:     "a-b",
+			expected: [][]int{{0, 1}},
+		},
+		{
+			name:     "fixed line",
+			opt:      options{patterns: []string{"cat"}, isExactMatch: true, isLineMatch: true},
+			data:     "cat",
+			expected: [][]int{{0, 3}},
+		},
+		{
+			name:     "fixed line ignore case",
+			opt:      options{patterns: []string{"кот"}, isExactMatch: true, isLineMatch: true, isIgnoreCase: true},
+			data:     "КоТ",
+			expected: [][]int{{0, 6}},
+		},
+		{
+			name:     "fixed line is not substring",
+			opt:      options{patterns: []string{"cat"}, isExactMatch: true, isLineMatch: true},
+			data:     "cat ",
+			expected: [][]int{},
+		},
+		{
+			name:     "many fixed lines",
+			opt:      options{patterns: []string{"dog", "cat"}, isExactMatch: true, isLineMatch: true},
+			data:     "cat",
+			expected: [][]int{{0, 3}},
+		},
+		{
+			name:     "regexp line",
+			opt:      options{patterns: []string{"a|ab+"}, isLineMatch: true},
+			data:     "abb",
+			expected: [][]int{{0, 3}},
+		},
+		{
+			name:     "regexp line is not substring",
+			opt:      options{patterns: []string{"b+"}, isLineMatch: true},
+			data:     "abb",
+			expected: [][]int{},
+		},
+		{
+			name:     "line takes precedence over word",
+			opt:      options{patterns: []string{"a"}, isLineMatch: true, isWordMatch: true},
+			data:     "a a",
+			expected: [][]int{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := newMatcher(&tc.opt)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, m.findAll(tc.data))
+			assert.Equal(t, len(tc.expected) > 0, m.isMatch(tc.data))
+		})
+	}
+}
+
+func TestInvalidRegexpPattern(t *testing.T) {
+	for _, patterns := range [][]string{{"a("}, {"a)|(b"}, {"ok", "[z-a]"}} {
+		_, err := newMatcher(&options{patterns: patterns})
+		assert.Error(t, err, patterns)
+	}
+}
+
+func TestFoldCase(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected string
+	}{
+		{data: "AbC", expected: "abc"},
+		{data: "ЁЖ", expected: "ёж"},
+		{data: "a\xffB", expected: "a\xffb"},
+		{data: "İx", expected: "İx"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.data, func(t *testing.T) {
+			res := foldCase(tc.data)
+			assert.Equal(t, tc.expected, res)
+			assert.Equal(t, len(tc.data), len(res))
+		})
+	}
+}
+
+func TestMatcherFirstFrom(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opt      options
+		data     string
+		pos      int
+		expected []int
+	}{
+		{
+			name:     "regexp line start is not search start",
+			opt:      options{patterns: []string{"^."}},
+			data:     "a-b",
+			pos:      2,
+			expected: nil,
+		},
+		{
+			name:     "regexp word boundary sees previous rune",
+			opt:      options{patterns: []string{`\bb`}},
+			data:     "abb b",
+			pos:      2,
+			expected: []int{4, 5},
+		},
+		{
+			name:     "regexp skips empty match",
+			opt:      options{patterns: []string{"x*"}},
+			data:     "aaxx",
+			pos:      1,
+			expected: []int{2, 4},
+		},
+		{
+			name:     "regexp after multibyte rune",
+			opt:      options{patterns: []string{`\Bт`}},
+			data:     "кот т",
+			pos:      2,
+			expected: []int{4, 6},
+		},
+		{
+			name:     "fixed",
+			opt:      options{patterns: []string{"ab"}, isExactMatch: true},
+			data:     "ab ab",
+			pos:      1,
+			expected: []int{3, 5},
+		},
+		{
+			name:     "many fixed",
+			opt:      options{patterns: []string{"ab", "b"}, isExactMatch: true},
+			data:     "ab ab",
+			pos:      1,
+			expected: []int{1, 2},
+		},
+		{
+			name:     "perl line start is not search start",
+			opt:      options{patterns: []string{"^."}, isPerlRegexp: true},
+			data:     "a-b",
+			pos:      1,
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := newMatcher(&tc.opt)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, m.firstFrom(tc.data, tc.pos))
+		})
+	}
+}
@@ -1,32 +1,87 @@
 package grep
 
 import (
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
-	"io"
-	"os"
-	"regexp"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	stdinPath = "-"
+	stdinName = "(standard input)"
+	// workDirPath is searched by recursive search without paths
+	workDirPath = "."
+	totalName   = "(total)"
+
+	binaryFilesBinary       = "binary"
+	binaryFilesText         = "text"
+	binaryFilesWithoutMatch = "without-match"
 )
 
 var (
-	errBadOpenFile = errors.New("grep: can't read file")
+	errBadOpenFile       = errors.New("grep: can't read file")
+	errNoPattern         = errors.New("grep: pattern is not specified")
+	errInvalidBinaryMode = errors.New("grep: binary files type must be binary, text or without-match")
+	errIsDirectory       = errors.New("is a directory")
+	errStopWalk          = errors.New("walk is stopped")
+	errInvalidColor      = errors.New("grep: color mode must be auto, always or never")
+	errInvalidParallel   = errors.New("grep: number of jobs must be positive")
+	errConflictMatchers  = errors.New("grep: -F and -P can't be used together")
+	errInvalidContext    = errors.New("grep: number of context lines must not be negative")
+	errConflictJSON      = errors.New("grep: --json can't be used with -c, -l, -L or -o")
+	errBacktrackLimit    = errors.New("grep: backtracking limit exceeded, some lines are treated as not matching")
 )
 
+// stringsFlag collects values of a flag that can be set several times
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, " ")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
 type optionsRaw struct {
-	numLinesBeforeMatch int
-	numLinesAfterMatch  int
-	numContextLines     int
+	Options
+	patterns        stringsFlag
+	patternFiles    stringsFlag
+	numContextLines int
 
 	isPrintMatchCount bool
-	isIgnoreCase      bool
-	isInvertSearch    bool
-	isExactMatch      bool
 	isPrintLineNum    bool
+	maxCount          int
+	isQuiet           bool
+	isSilent          bool
+
+	isRecursive       bool
+	isWithFilename    bool
+	isNoFilename      bool
+	isListMatching    bool
+	isListNonMatching bool
+	isBinaryAsText    bool
+	isSkipBinary      bool
+	binaryFiles       string
+	includeGlobs      stringsFlag
+	excludeGlobs      stringsFlag
+
+	isOnlyMatching    bool
+	isPrintByteOffset bool
+	color             colorFlag
+	isJSON            bool
+
+	parallel int
 }
 
+// options are compiled options of Searcher and options of command line only
 type options struct {
-	pattern             string
+	patterns            []string
+	matcher             matcher
 	numLinesBeforeMatch int
 	numLinesAfterMatch  int
 
@@ -34,82 +89,240 @@ type options struct {
 	isIgnoreCase      bool
 	isInvertSearch    bool
 	isExactMatch      bool
+	isPerlRegexp      bool
 	isPrintLineNum    bool
-	reader            io.ReadCloser
+	isWordMatch       bool
+	isLineMatch       bool
+	// reading stops after maxCount selected lines if hasMaxCount is set
+	hasMaxCount bool
+	maxCount    int
+	// isQuiet is true if nothing is printed and search stops at the first
+	// selected line
+	isQuiet  bool
+	isSilent bool
+
+	paths             []string
+	isRecursive       bool
+	isPrintFilename   bool
+	isListMatching    bool
+	isListNonMatching bool
+	binaryFiles       string
+	includeGlobs      []string
+	excludeGlobs      []string
+	// isSearchZip is true if compressed files are decompressed
+	isSearchZip bool
+
+	isOnlyMatching    bool
+	isPrintByteOffset bool
+	colors            colors
+	isJSON            bool
+
+	// parallel is the number of files searched at once
+	parallel int
+	// isPrintTotal is true if -j is set, so counts of files are followed
+	// by total count
+	isPrintTotal bool
 }
 
 func newOptions(args []string) (*options, error) {
 	optRaw := &optionsRaw{}
 	fs := flag.NewFlagSet("flags", flag.ContinueOnError)
-	fs.IntVar(&optRaw.numLinesAfterMatch, "A", 0, "show N lines after matched line")
-	fs.IntVar(&optRaw.numLinesBeforeMatch, "B", 0, "show N lines before matched line")
+	fs.Var(&optRaw.patterns, "e", "search for `PATTERN`, can be repeated")
+	fs.Var(&optRaw.patternFiles, "f", "search for patterns from `FILE`, one per line, can be repeated")
+	fs.IntVar(&optRaw.After, "A", 0, "show N lines after matched line")
+	fs.IntVar(&optRaw.Before, "B", 0, "show N lines before matched line")
 	fs.IntVar(&optRaw.numContextLines, "C", 0, "show N lines before and N lines after matched line")
 	fs.BoolVar(&optRaw.isPrintMatchCount, "c", false, "print matches count")
-	fs.BoolVar(&optRaw.isInvertSearch, "v", false, "exclude lines that contain pattern")
-	fs.BoolVar(&optRaw.isIgnoreCase, "i", false, "ignore case")
-	fs.BoolVar(&optRaw.isExactMatch, "F", false, "check for exact matched entries instead of regexp pattern")
-	fs.BoolVar(&optRaw.isPrintMatchCount, "n", false, "print line number before each line")
+	fs.BoolVar(&optRaw.Invert, "v", false, "exclude lines that contain pattern")
+	fs.BoolVar(&optRaw.IgnoreCase, "i", false, "ignore case")
+	fs.BoolVar(&optRaw.Fixed, "F", false, "check for exact matched entries instead of regexp pattern")
+	fs.BoolVar(&optRaw.Perl, "P", false, "pattern is Perl-compatible regexp with lookarounds and backreferences")
+	fs.BoolVar(&optRaw.isPrintLineNum, "n", false, "print line number before each line")
+	fs.BoolVar(&optRaw.isQuiet, "q", false, "print nothing, exit with zero status at the first selected line")
+	fs.BoolVar(&optRaw.isSilent, "s", false, "suppress error messages about unreadable files")
+	fs.BoolVar(&optRaw.Word, "w", false, "select only lines with matches that form whole words")
+	fs.BoolVar(&optRaw.Line, "x", false, "select only lines that match as a whole")
+	fs.IntVar(&optRaw.maxCount, "m", -1, "stop reading a file after `NUM` selected lines, negative means no limit")
+	fs.BoolVar(&optRaw.isRecursive, "r", false, "search files in directories recursively")
+	fs.Var(&optRaw.includeGlobs, "include", "search only files which base name matches `GLOB`, can be repeated")
+	fs.Var(&optRaw.excludeGlobs, "exclude", "skip files which base name matches `GLOB`, can be repeated")
+	fs.BoolVar(&optRaw.isWithFilename, "H", false, "print file name for each match")
+	fs.BoolVar(&optRaw.isNoFilename, "h", false, "do not print file names")
+	fs.BoolVar(&optRaw.isListMatching, "l", false, "print only names of files with matches")
+	fs.BoolVar(&optRaw.isListNonMatching, "L", false, "print only names of files without matches")
+	fs.StringVar(&optRaw.binaryFiles, "binary-files", binaryFilesBinary, "treat binary files as `TYPE`: binary, text or without-match")
+	fs.BoolVar(&optRaw.isBinaryAsText, "a", false, "treat binary files as text, same as --binary-files=text")
+	fs.BoolVar(&optRaw.isSkipBinary, "I", false, "skip binary files, same as --binary-files=without-match")
+	fs.BoolVar(&optRaw.Decompress, "Z", false, "search in decompressed content of gzip, bzip2, zstd and xz files")
+	fs.BoolVar(&optRaw.Decompress, "search-zip", false, "same as -Z")
+	fs.BoolVar(&optRaw.isOnlyMatching, "o", false, "print only matched parts of lines, each on its own line")
+	fs.BoolVar(&optRaw.isPrintByteOffset, "b", false, "print byte offset of line, or of match with -o, before each line")
+	fs.Var(&optRaw.color, "color", "highlight matches, `WHEN` is auto, always or never")
+	fs.BoolVar(&optRaw.isJSON, "json", false, "print results as JSON lines")
+	fs.IntVar(&optRaw.parallel, "j", 1, "search `N` files at once, output is written in order of files, -c adds total count")
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
 	}
 
-	rawPattern := fs.Arg(0)
-	filepath := fs.Arg(1)
+	paths := fs.Args()
+	patterns, err := optRaw.getPatterns()
+	if err != nil {
+		return nil, err
+	}
+	if len(optRaw.patterns) == 0 && len(optRaw.patternFiles) == 0 {
+		if len(paths) == 0 {
+			return nil, errNoPattern
+		}
+		patterns = splitPatterns(paths[0])
+		paths = paths[1:]
+	}
+	if len(paths) == 0 {
+		paths = []string{stdinPath}
+		if optRaw.isRecursive {
+			paths = []string{workDirPath}
+		}
+	}
 
-	pattern, err := getPattern(rawPattern, optRaw.isExactMatch, optRaw.isIgnoreCase)
+	binaryFiles, err := optRaw.getBinaryFiles()
 	if err != nil {
 		return nil, err
 	}
 
-	linesBefore, linesAfter := optRaw.getNumLinesBeforeAndAfter()
-	reader, err := getReader(filepath)
+	if err := validateGlobs(optRaw.includeGlobs); err != nil {
+		return nil, err
+	}
+	if err := validateGlobs(optRaw.excludeGlobs); err != nil {
+		return nil, err
+	}
+
+	if optRaw.isJSON && (optRaw.isPrintMatchCount || optRaw.isListMatching || optRaw.isListNonMatching || optRaw.isOnlyMatching) {
+		return nil, errConflictJSON
+	}
+	if optRaw.parallel < 1 {
+		return nil, errInvalidParallel
+	}
+
+	optRaw.Patterns = patterns
+	optRaw.Before = maxInt(optRaw.Before, optRaw.numContextLines)
+	optRaw.After = maxInt(optRaw.After, optRaw.numContextLines)
+	opt, err := optRaw.compile()
 	if err != nil {
 		return nil, err
 	}
 
-	opt := &options{
-		pattern:             pattern,
-		numLinesBeforeMatch: linesBefore,
-		numLinesAfterMatch:  linesAfter,
+	opt.isPrintMatchCount = optRaw.isPrintMatchCount
+	opt.isPrintLineNum = optRaw.isPrintLineNum
+	opt.hasMaxCount = optRaw.maxCount >= 0
+	opt.maxCount = optRaw.maxCount
+	opt.isQuiet = optRaw.isQuiet
+	opt.isSilent = optRaw.isSilent
 
-		isPrintMatchCount: optRaw.isPrintMatchCount,
-		isIgnoreCase:      optRaw.isIgnoreCase,
-		isInvertSearch:    optRaw.isInvertSearch,
-		isExactMatch:      optRaw.isExactMatch,
-		isPrintLineNum:    optRaw.isPrintLineNum,
-		reader:            reader,
+	opt.paths = paths
+	opt.isRecursive = optRaw.isRecursive
+	opt.isPrintFilename = optRaw.isPrintFilename(len(paths))
+	opt.isListMatching = optRaw.isListMatching
+	opt.isListNonMatching = optRaw.isListNonMatching
+	opt.binaryFiles = binaryFiles
+	opt.includeGlobs = optRaw.includeGlobs
+	opt.excludeGlobs = optRaw.excludeGlobs
+
+	opt.isOnlyMatching = optRaw.isOnlyMatching
+	opt.isPrintByteOffset = optRaw.isPrintByteOffset
+	opt.colors = getColors(string(optRaw.color))
+	opt.isJSON = optRaw.isJSON
+	opt.parallel = optRaw.parallel
+	fs.Visit(func(f *flag.Flag) {
+		opt.isPrintTotal = opt.isPrintTotal || f.Name == "j"
+	})
+	if opt.isQuiet {
+		// search stops at the first selected line, files are searched one
+		// by one to not read the following ones in vain
+		opt.parallel = 1
 	}
 	return opt, nil
 }
 
-func (o *options) GetExactPattern() string {
-	return getExactPattern(o.pattern, o.isIgnoreCase)
+func (o *options) isMaxCountReached(count int) bool {
+	return o.hasMaxCount && count >= o.maxCount
 }
 
-func (o *options) GetRegExpPattern() *regexp.Regexp {
-	r, _ := getRegexpPattern(o.pattern, o.isIgnoreCase)
-	return regexp.MustCompile(r)
+// total count is printed after counts of files in parallel search if
+// there may be more than one file, plain -c output is the same as in grep
+func (o *options) isPrintTotalCount() bool {
+	return o.isPrintTotal && o.isPrintMatchCount && o.isPrintFilename && !o.isListMatching && !o.isListNonMatching
 }
 
-func (o *optionsRaw) getNumLinesBeforeAndAfter() (int, int) {
-	before := maxInt(o.numLinesBeforeMatch, o.numContextLines)
-	after := maxInt(o.numLinesAfterMatch, o.numContextLines)
-	return before, after
-}
+// getPatterns returns patterns of -e flags and pattern files
+func (o *optionsRaw) getPatterns() ([]string, error) {
+	out := make([]string, 0)
+	for _, v := range o.patterns {
+		out = append(out, splitPatterns(v)...)
+	}
 
-func getReader(filePath string) (io.ReadCloser, error) {
-	if stat, err := os.Stdin.Stat(); err == nil {
-		if (stat.Mode() & os.ModeCharDevice) == 0 {
-			return os.Stdin, nil
+	for _, path := range o.patternFiles {
+		patterns, err := readPatternFile(path)
+		if err != nil {
+			return nil, err
 		}
+		out = append(out, patterns...)
 	}
+	return out, nil
+}
 
-	file, err := os.Open(filePath)
+// splitPatterns splits new line separated patterns
+func splitPatterns(s string) []string {
+	return strings.Split(s, "\n")
+}
+
+// readPatternFile returns lines of the file, empty file has no patterns
+func readPatternFile(path string) ([]string, error) {
+	in, err := openInput(path)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "unable to open file %s: %v\n", filePath, err)
-		return nil, errBadOpenFile
+		return nil, fmt.Errorf("grep: %w", err)
 	}
+	defer in.reader.Close()
 
-	return file, nil
+	out := make([]string, 0)
+	scanner := bufio.NewScanner(in.reader)
+	scanner.Buffer(nil, maxLineSize)
+	for scanner.Scan() {
+		out = append(out, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("grep: %s: %w", path, err)
+	}
+	return out, nil
+}
+
+// file names are printed if there may be more than one file to search
+func (o *optionsRaw) isPrintFilename(numPaths int) bool {
+	if o.isWithFilename || o.isNoFilename {
+		return o.isWithFilename
+	}
+	return numPaths > 1 || o.isRecursive
+}
+
+func (o *optionsRaw) getBinaryFiles() (string, error) {
+	switch {
+	case o.isBinaryAsText:
+		return binaryFilesText, nil
+	case o.isSkipBinary:
+		return binaryFilesWithoutMatch, nil
+	}
+
+	switch o.binaryFiles {
+	case binaryFilesBinary, binaryFilesText, binaryFilesWithoutMatch:
+		return o.binaryFiles, nil
+	}
+	return "", errInvalidBinaryMode
+}
+
+func validateGlobs(globs []string) error {
+	for _, v := range globs {
+		if _, err := filepath.Match(v, ""); err != nil {
+			return fmt.Errorf("grep: invalid glob %s: %w", v, err)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,74 @@
+package grep
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOptionsPatterns(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"patterns.txt": "p1\np2\n",
+		"empty.txt":    "",
+	})
+	patternFile := filepath.Join(dir, "patterns.txt")
+	emptyFile := filepath.Join(dir, "empty.txt")
+
+	testCases := []struct {
+		name     string
+		args     []string
+		patterns []string
+		paths    []string
+		isError  bool
+	}{
+		{name: "positional", args: []string{"p", "a.txt"}, patterns: []string{"p"}, paths: []string{"a.txt"}},
+		{name: "positional with new line", args: []string{"p1\np2"}, patterns: []string{"p1", "p2"}, paths: []string{stdinPath}},
+		{name: "flags", args: []string{"-e", "p1", "-e", "p2", "a.txt"}, patterns: []string{"p1", "p2"}, paths: []string{"a.txt"}},
+		{name: "file", args: []string{"-f", patternFile, "-e", "p3"}, patterns: []string{"p3", "p1", "p2"}, paths: []string{stdinPath}},
+		{name: "empty file", args: []string{"-f", emptyFile, "a.txt"}, patterns: []string{}, paths: []string{"a.txt"}},
+		{name: "missing file", args: []string{"-f", filepath.Join(dir, "x.txt")}, isError: true},
+		{name: "no pattern", args: []string{}, isError: true},
+		{name: "invalid regexp", args: []string{"-e", "a("}, isError: true},
+		{name: "perl regexp", args: []string{"-P", `(?<=a)b`}, patterns: []string{`(?<=a)b`}, paths: []string{stdinPath}},
+		{name: "perl regexp without group", args: []string{"-P", `(?<=a)b\1`}, isError: true},
+		{name: "fixed and perl", args: []string{"-F", "-P", "a"}, isError: true},
+		{name: "json and list", args: []string{"--json", "-l", "a"}, isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt, err := newOptions(tc.args)
+			if tc.isError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.patterns, opt.patterns)
+			assert.Equal(t, tc.paths, opt.paths)
+			assert.NotNil(t, opt.matcher)
+		})
+	}
+}
+
+func TestNewOptionsOutputFlags(t *testing.T) {
+	opt, err := newOptions([]string{"-n", "a"})
+	assert.NoError(t, err)
+	assert.True(t, opt.isPrintLineNum)
+	assert.False(t, opt.isPrintMatchCount)
+
+	opt, err = newOptions([]string{"-q", "-s", "-j", "4", "a"})
+	assert.NoError(t, err)
+	assert.True(t, opt.isQuiet)
+	assert.True(t, opt.isSilent)
+	assert.Equal(t, 1, opt.parallel)
+
+	opt, err = newOptions([]string{"-c", "a", "f1", "f2"})
+	assert.NoError(t, err)
+	assert.False(t, opt.isPrintTotalCount())
+
+	opt, err = newOptions([]string{"-c", "-j", "1", "a", "f1", "f2"})
+	assert.NoError(t, err)
+	assert.True(t, opt.isPrintTotalCount())
+}
@@ -0,0 +1,77 @@
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// searchJob is a file searched by a worker, its output is buffered until
+// outputs of all previous files are written
+type searchJob struct {
+	path  string
+	out   bytes.Buffer
+	count int
+	err   error
+	done  chan struct{}
+	// groups tell whether the output starts with a group of lines that
+	// needs separator, output of the file doesn't know previous files
+	groups groupSeparator
+}
+
+// searchParallel searches files by opt.parallel workers and writes
+// results in order of files. At most opt.parallel files wait for their
+// turn to be written, so memory is bounded by their outputs.
+func searchParallel(ctx context.Context, opt *options, w *bufio.Writer, errs *errorReporter) searchStats {
+	jobs := make(chan *searchJob)
+	queue := make(chan *searchJob, opt.parallel)
+
+	go func() {
+		walkPaths(opt, func(path string, err error) bool {
+			job := &searchJob{path: path, err: err, done: make(chan struct{})}
+			queue <- job
+			if err != nil {
+				close(job.done)
+				return true
+			}
+			jobs <- job
+			return true
+		})
+		close(jobs)
+		close(queue)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < opt.parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				jw := bufio.NewWriter(&job.out)
+				job.count, job.err = searchPath(ctx, opt, job.path, jw, &job.groups)
+				jw.Flush()
+				close(job.done)
+			}
+		}()
+	}
+
+	var stats searchStats
+	var groups groupSeparator
+	for job := range queue {
+		<-job.done
+		if groups.isGroupPrinted && job.groups.isFirstOmitted {
+			fmt.Fprintln(w, paint(opt.colors.separator, groupSep))
+		}
+		groups.isGroupPrinted = groups.isGroupPrinted || job.groups.isGroupPrinted
+		w.Write(job.out.Bytes())
+		if job.err != nil {
+			errs.report(job.path, job.err)
+			continue
+		}
+		stats.add(job.count)
+	}
+	wg.Wait()
+	return stats
+}
@@ -0,0 +1,130 @@
+package grep
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchParallel(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{}
+	paths := []string{}
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("f%02d.txt", i)
+		files[name] = strings.Repeat("alpha\nbeta\n", i)
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	writeTestFiles(t, dir, files)
+	paths = append(paths, filepath.Join(dir, "missing.txt"))
+
+	testCases := []struct {
+		name  string
+		opt   options
+		total int
+	}{
+		{
+			name:  "lines",
+			opt:   options{patterns: []string{"beta"}, isPrintFilename: true, isPrintLineNum: true},
+			total: 190,
+		},
+		{
+			name:  "count",
+			opt:   options{patterns: []string{"beta"}, isPrintFilename: true, isPrintMatchCount: true},
+			total: 190,
+		},
+		{
+			name: "list",
+			opt:  options{patterns: []string{"beta"}, isListNonMatching: true},
+			// reading of file stops at the first selected line
+			total: 19,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt := compileMatcher(t, &tc.opt)
+			opt.paths = paths
+
+			search := func(parallel int) (string, int, error) {
+				var out bytes.Buffer
+				w := bufio.NewWriter(&out)
+				errs := &errorReporter{}
+				opt.parallel = parallel
+				var stats searchStats
+				if parallel > 1 {
+					stats = searchParallel(context.Background(), opt, w, errs)
+				} else {
+					stats = searchSequential(context.Background(), opt, w, errs)
+				}
+				assert.NoError(t, w.Flush())
+				assert.Equal(t, 20, stats.searches)
+				return out.String(), stats.matchedLines, errs.err
+			}
+
+			expected, expectedTotal, err := search(1)
+			assert.ErrorIs(t, err, errBadOpenFile)
+			assert.Equal(t, tc.total, expectedTotal)
+
+			for _, parallel := range []int{2, 8} {
+				res, total, err := search(parallel)
+				assert.Equal(t, expected, res)
+				assert.Equal(t, expectedTotal, total)
+				assert.ErrorIs(t, err, errBadOpenFile)
+			}
+		})
+	}
+}
+
+func TestSearchGroupSeparatorBetweenFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"s1.txt": "foo\nx\n",
+		"s2.txt": "nope\n",
+		"s3.txt": "foo\ny\nz\nfoo\n",
+	})
+	opt := compileMatcher(t, &options{patterns: []string{"foo"}, numLinesAfterMatch: 1})
+	for _, name := range []string{"s1.txt", "s2.txt", "s3.txt"} {
+		opt.paths = append(opt.paths, filepath.Join(dir, name))
+	}
+	expected := "foo\nx\n--\nfoo\ny\n--\nfoo\n"
+
+	for _, parallel := range []int{1, 3} {
+		var out bytes.Buffer
+		w := bufio.NewWriter(&out)
+		opt.parallel = parallel
+		if parallel > 1 {
+			searchParallel(context.Background(), opt, w, &errorReporter{})
+		} else {
+			searchSequential(context.Background(), opt, w, &errorReporter{})
+		}
+		assert.NoError(t, w.Flush())
+		assert.Equal(t, expected, out.String())
+	}
+}
+
+func TestIsPrintTotalCount(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opt      options
+		expected bool
+	}{
+		{name: "count of many files", opt: options{isPrintMatchCount: true, isPrintFilename: true, isPrintTotal: true}, expected: true},
+		{name: "count of many files without -j", opt: options{isPrintMatchCount: true, isPrintFilename: true}, expected: false},
+		{name: "count of one file", opt: options{isPrintMatchCount: true, isPrintTotal: true}, expected: false},
+		{name: "lines", opt: options{isPrintFilename: true, isPrintTotal: true}, expected: false},
+		{name: "list", opt: options{isPrintMatchCount: true, isPrintFilename: true, isListMatching: true, isPrintTotal: true}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.opt.isPrintTotalCount())
+		})
+	}
+}
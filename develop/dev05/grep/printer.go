@@ -0,0 +1,192 @@
+package grep
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	matchSep   = ":"
+	contextSep = "-"
+	groupSep   = "--"
+)
+
+// numberedLine is a line with its number starting from 1 and byte offset
+// of its start in the input
+type numberedLine struct {
+	num    int
+	offset int64
+	text   string
+}
+
+// groupSeparator is shared by printers of files, so groups of lines of
+// different files are separated too. isFirstOmitted is set if printer
+// didn't print separator before its first group because no group was
+// printed before.
+type groupSeparator struct {
+	isGroupPrinted bool
+	isFirstOmitted bool
+}
+
+// contextPrinter prints selected lines with context. Only the last
+// `before` lines are kept in memory in ring buffer, lines after selected
+// one are printed while countdown is positive. Groups of lines that
+// are not adjacent are separated by groupSep.
+type contextPrinter struct {
+	w                 io.Writer
+	filename          string
+	matcher           matcher
+	colors            colors
+	isPrintLineNum    bool
+	isPrintByteOffset bool
+	isOnlyMatching    bool
+	before            int
+	after             int
+	// emit is called for printed lines instead of writing them to w
+	// if it is set
+	emit func(l numberedLine, isContext bool) error
+
+	buf       []numberedLine
+	bufStart  int
+	bufSize   int
+	afterLeft int
+	lastNum   int
+	groups    *groupSeparator
+}
+
+func newContextPrinter(w io.Writer, opt *options, filename string, m matcher) *contextPrinter {
+	p := &contextPrinter{
+		w:                 w,
+		filename:          filename,
+		matcher:           m,
+		colors:            opt.colors,
+		isPrintLineNum:    opt.isPrintLineNum,
+		isPrintByteOffset: opt.isPrintByteOffset,
+		isOnlyMatching:    opt.isOnlyMatching,
+		groups:            &groupSeparator{},
+	}
+	if !opt.isOnlyMatching {
+		p.before = opt.numLinesBeforeMatch
+		p.after = opt.numLinesAfterMatch
+	}
+	p.buf = make([]numberedLine, p.before)
+	return p
+}
+
+// add handles the next line of input, returns error of emit
+func (p *contextPrinter) add(l numberedLine, isSelected bool) error {
+	if p.isOnlyMatching {
+		if isSelected {
+			p.printMatches(l)
+		}
+		return nil
+	}
+
+	if !isSelected {
+		if p.afterLeft > 0 {
+			p.afterLeft--
+			return p.print(l, contextSep)
+		}
+		p.push(l)
+		return nil
+	}
+
+	for i := 0; i < p.bufSize; i++ {
+		if err := p.print(p.buf[(p.bufStart+i)%len(p.buf)], contextSep); err != nil {
+			return err
+		}
+	}
+	p.bufStart, p.bufSize = 0, 0
+
+	p.afterLeft = p.after
+	return p.print(l, matchSep)
+}
+
+// hasPendingContext reports whether lines after the last selected one
+// are still to be printed
+func (p *contextPrinter) hasPendingContext() bool {
+	return p.afterLeft > 0
+}
+
+// push adds line to ring buffer dropping the oldest line if buffer is full
+func (p *contextPrinter) push(l numberedLine) {
+	if len(p.buf) == 0 {
+		return
+	}
+	if p.bufSize < len(p.buf) {
+		p.buf[(p.bufStart+p.bufSize)%len(p.buf)] = l
+		p.bufSize++
+		return
+	}
+	p.buf[p.bufStart] = l
+	p.bufStart = (p.bufStart + 1) % len(p.buf)
+}
+
+func (p *contextPrinter) print(l numberedLine, sep string) error {
+	if p.emit != nil {
+		return p.emit(l, sep == contextSep)
+	}
+
+	isContext := p.before > 0 || p.after > 0
+	isGroupStart := p.lastNum == 0 || l.num > p.lastNum+1
+	if isContext && isGroupStart {
+		if p.groups.isGroupPrinted {
+			fmt.Fprintln(p.w, paint(p.colors.separator, groupSep))
+		} else if p.lastNum == 0 {
+			p.groups.isFirstOmitted = true
+		}
+	}
+	p.groups.isGroupPrinted = true
+	p.lastNum = l.num
+
+	p.printPrefix(l.num, l.offset, sep)
+
+	code := p.colors.selectedMatch
+	if sep == contextSep {
+		code = p.colors.contextMatch
+	}
+	if code == "" {
+		fmt.Fprintln(p.w, l.text)
+		return nil
+	}
+	fmt.Fprintln(p.w, highlight(l.text, p.matcher.findAll(l.text), code))
+	return nil
+}
+
+// printMatches prints every match of the line on its own line
+func (p *contextPrinter) printMatches(l numberedLine) {
+	for _, m := range p.matcher.findAll(l.text) {
+		p.printPrefix(l.num, l.offset+int64(m[0]), matchSep)
+		fmt.Fprintln(p.w, paint(p.colors.selectedMatch, l.text[m[0]:m[1]]))
+	}
+}
+
+func (p *contextPrinter) printPrefix(num int, offset int64, sep string) {
+	sep = paint(p.colors.separator, sep)
+	if p.filename != "" {
+		fmt.Fprintf(p.w, "%s%s", paint(p.colors.filename, p.filename), sep)
+	}
+	if p.isPrintLineNum {
+		fmt.Fprintf(p.w, "%s%s", paint(p.colors.lineNum, fmt.Sprint(num)), sep)
+	}
+	if p.isPrintByteOffset {
+		fmt.Fprintf(p.w, "%s%s", paint(p.colors.byteOffset, fmt.Sprint(offset)), sep)
+	}
+}
+
+// highlight paints matches of the string
+func highlight(s string, matches [][]int, code string) string {
+	if len(matches) == 0 {
+		return s
+	}
+
+	out := make([]byte, 0, len(s)+len(matches)*16)
+	last := 0
+	for _, m := range matches {
+		out = append(out, s[last:m[0]]...)
+		out = append(out, paint(code, s[m[0]:m[1]])...)
+		last = m[1]
+	}
+	out = append(out, s[last:]...)
+	return string(out)
+}
@@ -0,0 +1,151 @@
+package grep
+
+import (
+	"context"
+	"io"
+)
+
+// Options configure Searcher. Zero value has no patterns, so it selects
+// no lines.
+type Options struct {
+	// Patterns are regexps in RE2 syntax unless Fixed or Perl is set,
+	// lines that match any of patterns are selected
+	Patterns []string
+
+	Fixed      bool // -F, patterns are fixed strings
+	Perl       bool // -P, patterns are Perl-compatible regexps
+	IgnoreCase bool // -i
+	Invert     bool // -v, lines that don't match are selected
+	Word       bool // -w, matches must form whole words
+	Line       bool // -x, matches must be whole lines
+
+	// Before and After are numbers of context lines around selected lines
+	Before int // -B
+	After  int // -A
+
+	// MaxCount is the number of selected lines after which search stops,
+	// zero means no limit. Trailing context of the last line is still found.
+	MaxCount int
+	// Decompress enables search in decompressed content of input if it is
+	// compressed by gzip, bzip2, zstd or xz
+	Decompress bool // -Z
+}
+
+// Match is a selected line or a context line around selected one
+type Match struct {
+	// LineNumber is the number of line in input starting from 1
+	LineNumber int
+	// Offset is the byte offset of the line in input
+	Offset int64
+	// Text is the line without line terminator
+	Text      string
+	IsContext bool
+	// Submatches are bounds of matches of patterns in Text, context lines
+	// and lines selected by inverted search have no submatches
+	Submatches []Submatch
+}
+
+// Submatch is a match of pattern in line, Start and End are byte offsets
+type Submatch struct {
+	Start int
+	End   int
+}
+
+// Searcher selects lines like grep utility. Searcher is safe for
+// concurrent use.
+type Searcher struct {
+	opt *options
+}
+
+// NewSearcher validates options and compiles patterns
+func NewSearcher(o Options) (*Searcher, error) {
+	opt, err := o.compile()
+	if err != nil {
+		return nil, err
+	}
+	return &Searcher{opt: opt}, nil
+}
+
+// Search reads lines of r and calls fn for every selected line and its
+// context lines in order of input. Search stops with the first error of
+// fn or of reading.
+func (s *Searcher) Search(ctx context.Context, r io.Reader, fn func(Match) error) error {
+	opt := s.opt
+	if opt.isSearchZip {
+		dr, err := openDecompressed(r)
+		if err != nil {
+			return err
+		}
+		defer dr.Close()
+		r = dr
+	}
+
+	printer := newContextPrinter(io.Discard, opt, "", opt.matcher)
+	printer.emit = func(l numberedLine, isContext bool) error {
+		return fn(s.newMatch(l, isContext))
+	}
+	_, err := scanLines(ctx, opt, r, printer, false)
+	return err
+}
+
+// MatchLine reports whether line is selected
+func (s *Searcher) MatchLine(line string) bool {
+	return isMatch(s.opt.matcher.isMatch(line), s.opt.isInvertSearch)
+}
+
+// IsLimitExceeded reports whether Perl-compatible patterns gave up matching
+// of some line because of backtracking limit, such lines are treated as
+// not matching
+func (s *Searcher) IsLimitExceeded() bool {
+	return isLimitExceeded(s.opt.matcher)
+}
+
+func (s *Searcher) newMatch(l numberedLine, isContext bool) Match {
+	m := Match{LineNumber: l.num, Offset: l.offset, Text: l.text, IsContext: isContext}
+	if isContext || s.opt.isInvertSearch {
+		return m
+	}
+	for _, v := range s.opt.matcher.findAll(l.text) {
+		m.Submatches = append(m.Submatches, Submatch{Start: v[0], End: v[1]})
+	}
+	return m
+}
+
+func (o *Options) compile() (*options, error) {
+	if o.Fixed && o.Perl {
+		return nil, errConflictMatchers
+	}
+	if o.Before < 0 || o.After < 0 {
+		return nil, errInvalidContext
+	}
+
+	patterns := o.Patterns
+	if patterns == nil {
+		patterns = []string{}
+	}
+
+	opt := &options{
+		patterns:            patterns,
+		numLinesBeforeMatch: o.Before,
+		numLinesAfterMatch:  o.After,
+
+		isIgnoreCase:   o.IgnoreCase,
+		isInvertSearch: o.Invert,
+		isExactMatch:   o.Fixed,
+		isPerlRegexp:   o.Perl,
+		isWordMatch:    o.Word,
+		isLineMatch:    o.Line,
+		hasMaxCount:    o.MaxCount > 0,
+		maxCount:       o.MaxCount,
+
+		binaryFiles: binaryFilesText,
+		isSearchZip: o.Decompress,
+		parallel:    1,
+	}
+
+	var err error
+	if opt.matcher, err = newMatcher(opt); err != nil {
+		return nil, err
+	}
+	return opt, nil
+}
@@ -0,0 +1,151 @@
+package grep
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearcherSearch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opt      Options
+		data     string
+		expected []Match
+	}{
+		{
+			name: "context",
+			opt:  Options{Patterns: []string{"b+"}, Before: 1, After: 1},
+			data: "a\nxbby\nc\nd\n",
+			expected: []Match{
+				{LineNumber: 1, Offset: 0, Text: "a", IsContext: true},
+				{LineNumber: 2, Offset: 2, Text: "xbby", Submatches: []Submatch{{Start: 1, End: 3}}},
+				{LineNumber: 3, Offset: 7, Text: "c", IsContext: true},
+			},
+		},
+		{
+			name: "invert",
+			opt:  Options{Patterns: []string{"b"}, Invert: true},
+			data: "a\nb\nc",
+			expected: []Match{
+				{LineNumber: 1, Offset: 0, Text: "a"},
+				{LineNumber: 3, Offset: 4, Text: "c"},
+			},
+		},
+		{
+			name: "ignore case fixed words",
+			opt:  Options{Patterns: []string{"кот", "пёс"}, Fixed: true, IgnoreCase: true, Word: true},
+			data: "Кот и ПЁС\nкотёнок\n",
+			expected: []Match{
+				{LineNumber: 1, Offset: 0, Text: "Кот и ПЁС", Submatches: []Submatch{{Start: 0, End: 6}, {Start: 10, End: 16}}},
+			},
+		},
+		{
+			name: "max count",
+			opt:  Options{Patterns: []string{"a"}, MaxCount: 1, After: 2},
+			data: "a1\nb\na2\n",
+			expected: []Match{
+				{LineNumber: 1, Offset: 0, Text: "a1", Submatches: []Submatch{{Start: 0, End: 1}}},
+				{LineNumber: 2, Offset: 3, Text: "b", IsContext: true},
+			},
+		},
+		{
+			name:     "no patterns",
+			data:     "a\n",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewSearcher(tc.opt)
+			assert.NoError(t, err)
+
+			var res []Match
+			err = s.Search(context.Background(), strings.NewReader(tc.data), func(m Match) error {
+				res = append(res, m)
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestSearcherSearchDecompress(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte("alpha\nbeta\n"))
+	assert.NoError(t, w.Close())
+
+	s, err := NewSearcher(Options{Patterns: []string{"beta"}, Decompress: true})
+	assert.NoError(t, err)
+
+	var res []string
+	err = s.Search(context.Background(), &buf, func(m Match) error {
+		res = append(res, m.Text)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"beta"}, res)
+}
+
+func TestSearcherSearchStops(t *testing.T) {
+	s, err := NewSearcher(Options{Patterns: []string{"a"}})
+	assert.NoError(t, err)
+	data := strings.Repeat("a\n", 10*ctxCheckInterval)
+
+	errStop := errors.New("stop")
+	count := 0
+	err = s.Search(context.Background(), strings.NewReader(data), func(m Match) error {
+		count++
+		if count == 2 {
+			return errStop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 2, count)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	count = 0
+	err = s.Search(ctx, strings.NewReader(data), func(m Match) error {
+		count++
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, count, ctxCheckInterval)
+}
+
+func TestSearcherMatchLine(t *testing.T) {
+	s, err := NewSearcher(Options{Patterns: []string{"^a"}, Invert: true})
+	assert.NoError(t, err)
+	assert.False(t, s.MatchLine("abc"))
+	assert.True(t, s.MatchLine("bac"))
+}
+
+func TestSearcherIsLimitExceeded(t *testing.T) {
+	s, err := NewSearcher(Options{Patterns: []string{"(a+)+b"}, Perl: true, Word: true})
+	assert.NoError(t, err)
+	assert.True(t, s.MatchLine("aab"))
+	assert.False(t, s.IsLimitExceeded())
+	assert.False(t, s.MatchLine(strings.Repeat("a", 40)))
+	assert.True(t, s.IsLimitExceeded())
+}
+
+func TestNewSearcherInvalidOptions(t *testing.T) {
+	for _, opt := range []Options{
+		{Patterns: []string{"a("}},
+		{Patterns: []string{"a"}, Fixed: true, Perl: true},
+		{Patterns: []string{"a"}, Before: -1},
+	} {
+		_, err := NewSearcher(opt)
+		assert.Error(t, err)
+	}
+}